@@ -0,0 +1,289 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/com"
+)
+
+// expandAddressElements 为没有子元素的 address 元素生成子元素（见 addressSubElements）
+func expandAddressElements(elements []*config.Element) {
+	for _, ele := range elements {
+		switch ele.Type {
+		case `langset`, `fieldset`, common.SUBFORM:
+			expandAddressElements(ele.Elements)
+		case common.ADDRESS:
+			if len(ele.Elements) == 0 {
+				ele.Elements = addressSubElements(ele)
+			}
+		}
+	}
+}
+
+// addressSubElements 返回 address 元素的子元素，没有子元素时生成街道、城市、地区、邮编和国家子元素
+// （名称为“<name>.<part>”，名称使用方括号时为“<name>[<part>]”），address 元素的值为默认的国家。address 元素不会被修改
+func addressSubElements(ele *config.Element) []*config.Element {
+	if len(ele.Elements) > 0 {
+		return ele.Elements
+	}
+	subs := make([]*config.Element, 0, len(fields.AddressParts))
+	for _, part := range fields.AddressParts {
+		sub := &config.Element{Type: common.TEXT, Name: prefixName(ele.Name, part, false)}
+		if part == fields.AddressCountry {
+			sub.Type = common.SELECT
+			sub.Value = ele.Value
+		}
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// addressElements 返回全部 address 元素
+func addressElements(elements []*config.Element) []*config.Element {
+	var list []*config.Element
+	for _, ele := range elements {
+		switch ele.Type {
+		case `langset`, `fieldset`, common.SUBFORM:
+			list = append(list, addressElements(ele.Elements)...)
+		case common.ADDRESS:
+			list = append(list, ele)
+		}
+	}
+	return list
+}
+
+// addressPartNames 返回 address 元素各部分的名称
+func addressPartNames(ele *config.Element) map[string]string {
+	names := map[string]string{}
+	for _, sub := range addressSubElements(ele) {
+		if part := sub.GetFieldName(); fields.IsAddressPart(part) {
+			names[part] = sub.Name
+		}
+	}
+	return names
+}
+
+// addressCountryChoices 返回国家选项（第一项为空）
+func addressCountryChoices() []*config.Choice {
+	countries := fields.AddressCountries()
+	choices := make([]*config.Choice, 0, len(countries)+1)
+	choices = append(choices, &config.Choice{Option: []string{``, ``}})
+	for _, v := range countries {
+		choices = append(choices, &config.Choice{Option: []string{v.Country, v.Name}})
+	}
+	return choices
+}
+
+// parseAddressElement 生成 address 元素的地址组合字段
+func (form *Form) parseAddressElement(model interface{}, ele *config.Element, t reflect.Type, v reflect.Value) *FieldSetType {
+	fs := form.NewFieldSet(ele.Name, form.labelFn(ele.Label))
+	if len(ele.Template) > 0 {
+		fs.SetTemplate(ele.Template)
+	}
+	fs.SetData("container", "fieldset")
+	fs.SetData(`formID`, form.ID)
+	for key, val := range ele.Data {
+		fs.SetData(key, val)
+	}
+	parts := map[string]*fields.Field{}
+	for _, sub := range addressSubElements(ele) { // 在 Init 之后添加的元素没有子元素
+		part := sub.GetFieldName()
+		if !fields.IsAddressPart(part) {
+			form.addBuildError(sub.Name, fmt.Errorf(`unknown part %q of address %s, expected one of %s`, part, ele.Name, strings.Join(fields.AddressParts, `, `)))
+			continue
+		}
+		if f := form.parseElement(model, sub, t, v); f != nil {
+			parts[part] = f
+		}
+	}
+	form.setAddressParts(fs, parts)
+	fs.SetLabelCols(ele.LabelCols)
+	fs.SetFieldCols(ele.FieldCols)
+	fs.SetHelpText(form.labelFn(ele.HelpText))
+	return fs
+}
+
+// addressFieldSetFromInstance 为 form_widget:"address" 的嵌套结构体生成地址组合字段，
+// 结构体中名为 Street、City、Region、PostalCode 和 Country 的字段分别对应地址的各部分，path 为结构体字段的路径
+func (form *Form) addressFieldSetFromInstance(value reflect.Value, path string, label string, useFieldValue bool) *FieldSetType {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value = reflect.New(value.Type().Elem())
+		}
+		value = value.Elem()
+	}
+	nameOf := func(name string) string {
+		if form.nameFn != nil {
+			return form.nameFn(name)
+		}
+		return name
+	}
+	parts := map[string]*fields.Field{}
+	if value.Kind() == reflect.Struct {
+		for _, part := range fields.AddressParts {
+			fv := value.FieldByName(com.Title(part))
+			if !fv.IsValid() {
+				continue
+			}
+			name := nameOf(path + `.` + com.Title(part))
+			var f *fields.Field
+			if part == fields.AddressCountry {
+				f = fields.SelectField(name, nil)
+			} else {
+				f = fields.TextField(name)
+			}
+			if fv = reflect.Indirect(fv); useFieldValue && fv.IsValid() {
+				f.SetValue(fmt.Sprint(fv.Interface()))
+			}
+			parts[part] = f
+		}
+	}
+	if label != `-` {
+		label = form.labelFn(label)
+	} else {
+		label = ``
+	}
+	fs := form.NewFieldSet(nameOf(path), label)
+	form.setAddressParts(fs, parts)
+	return fs
+}
+
+// setAddressParts 设置地址组合字段的各部分并按照所选国家的地址格式排列，没有选项的国家字段使用内置的国家列表
+func (form *Form) setAddressParts(fs *FieldSetType, parts map[string]*fields.Field) {
+	fs.AddClass(common.ADDRESS)
+	if fs.Template == `fieldset` {
+		fs.SetTemplate(common.ADDRESS)
+	}
+	fs.addressParts = parts
+	if f, ok := parts[fields.AddressCountry]; ok && f.Type == common.SELECT && len(f.ChoiceKeys) == 0 {
+		f.SetChoicesFromConfig(addressCountryChoices(), form.labelFn)
+		f.SetSelected(f.Value)
+	}
+	fs.SetData(`addressFormats`, form.addressLayouts())
+	form.layoutAddress(fs)
+}
+
+// addressLayoutPart 客户端脚本使用的地址格式中的一部分
+type addressLayoutPart struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+// addressLayouts 返回全部国家的地址格式（标签已翻译），键为国家代码，默认格式的键为空字符串。
+// 所选国家改变后地址组合字段的脚本使用它重新设置各部分的标签、必填属性和是否显示
+func (form *Form) addressLayouts() map[string][]addressLayoutPart {
+	layout := func(format *fields.AddressFormat) []addressLayoutPart {
+		parts := make([]addressLayoutPart, len(format.Parts))
+		for i, p := range format.Parts {
+			parts[i] = addressLayoutPart{Name: p.Name, Label: form.labelFn(p.Label), Required: p.Required}
+		}
+		return parts
+	}
+	layouts := map[string][]addressLayoutPart{``: layout(fields.DefaultAddressFormat)}
+	for _, format := range fields.AddressCountries() {
+		layouts[strings.ToUpper(format.Country)] = layout(format)
+	}
+	return layouts
+}
+
+// layoutAddress 按照所选国家的地址格式排列地址的各部分并设置标签和必填属性。
+// 格式中没有的部分排在后面（由脚本隐藏），这样选择其它国家后这些部分也可以填写
+func (form *Form) layoutAddress(fs *FieldSetType) {
+	var country string
+	if f, ok := fs.addressParts[fields.AddressCountry]; ok {
+		country = f.Value
+	}
+	format := fields.GetAddressFormat(country)
+	fs.FieldList = make([]config.FormElement, 0, len(fs.addressParts))
+	fs.fieldMap = map[string]int{}
+	fs.data = nil
+	for _, p := range format.Parts {
+		f, ok := fs.addressParts[p.Name]
+		if !ok {
+			continue
+		}
+		f.SetLabel(form.labelFn(p.Label))
+		if p.Required {
+			f.AddTag(`required`)
+		} else {
+			f.RemoveTag(`required`)
+		}
+		fs.addField(f)
+	}
+	for _, name := range fields.AddressParts {
+		f, ok := fs.addressParts[name]
+		if !ok {
+			continue
+		}
+		if _, ok := format.Part(name); ok {
+			continue
+		}
+		label := name
+		if p, ok := fields.DefaultAddressFormat.Part(name); ok {
+			label = p.Label
+		}
+		f.SetLabel(form.labelFn(label))
+		f.RemoveTag(`required`)
+		fs.addField(f)
+	}
+}
+
+// layoutAddresses 重新排列全部地址组合字段（回填数据后国家可能已改变）
+func (form *Form) layoutAddresses(elements []config.FormElement) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case *FieldSetType:
+			if v.addressParts != nil {
+				form.layoutAddress(v)
+			} else {
+				form.layoutAddresses(v.FieldList)
+			}
+		case *LangSetType:
+			for _, language := range v.Languages {
+				form.layoutAddresses(language.Fields())
+			}
+		}
+	}
+}
+
+// validAddress 按照提交的国家的地址格式验证地址中必填的部分，parts 为地址各部分的提交名称
+func (form *Form) validAddress(values url.Values, parts map[string]string) error {
+	var country string
+	if name, ok := parts[fields.AddressCountry]; ok {
+		country = values.Get(name)
+	}
+	var firstErr error
+	for _, p := range fields.GetAddressFormat(country).Parts {
+		name, ok := parts[p.Name]
+		if !ok || !p.Required || len(strings.TrimSpace(values.Get(name))) > 0 {
+			continue
+		}
+		form.Validate().SetError(name, ErrRequired.Error())
+		if firstErr == nil {
+			firstErr = fmt.Errorf(`%s: %w`, name, ErrRequired)
+		}
+	}
+	return firstErr
+}
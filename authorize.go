@@ -0,0 +1,158 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+)
+
+// Access 当前用户对表单元素的访问权限
+type Access int
+
+const (
+	AccessEdit     Access = iota // 可编辑
+	AccessReadonly               // 只读（选项类字段为禁用）
+	AccessDisabled               // 禁用
+	AccessHidden                 // 不显示
+)
+
+// Writable 是否接受客户端提交的值。只有可编辑的元素接受提交的值，其它元素提交的值会被 Filter 和 Bind 丢弃
+func (a Access) Writable() bool {
+	return a == AccessEdit
+}
+
+// Authorizer 返回当前用户对元素的访问权限。fieldset、langset、address 和 subform 的权限同时限制其子元素。
+// 按结构体生成的表单中，每个字段（包括嵌套的结构体）对应一个元素：类型为 form_widget，名称为字段路径（如 Profile.Name），
+// 权限为 form_perm 标签的值，嵌套结构体的权限同时限制其字段
+type Authorizer func(ele *config.Element) Access
+
+// PermAuthorizer 按照元素所需的权限（见 config.Element.Permission）授权：
+// 没有设置权限或者 can 返回 true 时可编辑，否则为 denied
+func PermAuthorizer(can func(perm string) bool, denied Access) Authorizer {
+	return func(ele *config.Element) Access {
+		perm := ele.Permission()
+		if len(perm) == 0 || can(perm) {
+			return AccessEdit
+		}
+		return denied
+	}
+}
+
+// SetAuthorizer 设置元素的授权函数，在 ParseModelElements 和 ParseModel 中隐藏、禁用元素或将元素设为只读，
+// 并在 Filter 和 Bind 中丢弃不可编辑元素提交的值
+func (f *Form) SetAuthorizer(fn Authorizer) *Form {
+	f.authorizer = fn
+	return f
+}
+
+// Authorizer 返回元素的授权函数
+func (f *Form) Authorizer() Authorizer {
+	return f.authorizer
+}
+
+// authorize 返回元素自身的访问权限（不包括父元素的限制）
+func (f *Form) authorize(ele *config.Element) Access {
+	if f.authorizer == nil {
+		return AccessEdit
+	}
+	return f.authorizer(ele)
+}
+
+// unwritableNames 返回配置中不可编辑的元素（包括受父元素限制的子元素）的名称，
+// langset 中的元素包括各语言的名称（与 config.GetNames 相同，例如“Language[en][title]”）
+func (f *Form) unwritableNames() map[string]bool {
+	names := map[string]bool{}
+	if f.authorizer == nil || f.config == nil {
+		return names
+	}
+	var walk func(elements []*config.Element, languages []*config.Language, denied bool)
+	walk = func(elements []*config.Element, languages []*config.Language, denied bool) {
+		for _, ele := range elements {
+			eleDenied := denied || !f.authorize(ele).Writable()
+			if eleDenied && len(ele.Name) > 0 {
+				names[ele.Name] = true
+				for _, lang := range languages {
+					names[lang.Name(ele.Name)] = true
+				}
+			}
+			if ele.Type == `langset` && ele.Languages != nil {
+				walk(ele.Elements, ele.Languages, eleDenied)
+				continue
+			}
+			walk(ele.Elements, languages, eleDenied)
+		}
+	}
+	walk(f.config.Elements, f.config.Languages, false)
+	return names
+}
+
+// structWritable 结构体字段及其所属的嵌套结构体是否都可编辑（见 fieldPlan.element）
+func (f *Form) structWritable(sf *structField) bool {
+	if f.authorizer == nil {
+		return true
+	}
+	for _, ele := range sf.Elements {
+		if !f.authorize(ele).Writable() {
+			return false
+		}
+	}
+	return true
+}
+
+// restrictElement 按照访问权限禁用元素（包括子元素）或将其设为只读
+func restrictElement(elem config.FormElement, access Access) config.FormElement {
+	if access != AccessReadonly && access != AccessDisabled {
+		return elem
+	}
+	switch v := elem.(type) {
+	case *fields.Field:
+		if access == AccessDisabled || !isReadonlyType(v.Type) {
+			v.Disabled()
+		} else {
+			v.AddTag(config.Readonly)
+		}
+	case *FieldSetType:
+		if v.addressParts != nil { // 包括当前国家不显示的部分
+			for _, part := range v.addressParts {
+				restrictElement(part, access)
+			}
+			break
+		}
+		for _, child := range v.FieldList {
+			restrictElement(child, access)
+		}
+	case *LangSetType:
+		for _, language := range v.Languages {
+			for _, child := range language.Fields() {
+				restrictElement(child, access)
+			}
+		}
+	case fields.FieldInterface:
+		v.Disabled()
+	}
+	return elem
+}
+
+// isReadonlyType 浏览器是否支持此类型字段的 readonly 属性（选项类字段不支持，只能禁用）
+func isReadonlyType(fieldType string) bool {
+	switch fieldType {
+	case common.SELECT, common.CHECKBOX, common.RADIO, common.SWITCH, common.RATING, common.RANGE, common.COLOR, common.FILE:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,782 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/com"
+	"github.com/webx-top/validation"
+)
+
+var (
+	ErrInvalidBindTarget = errors.New(`the model to bind must be a non-nil pointer`)
+	ErrInvalidValue      = errors.New(`Invalid value`)
+	ErrRequired          = errors.New(`Can not be empty`)
+	ErrUnexpectedField   = errors.New(`unexpected field`)
+
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	valuerType          = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	// 时间字段在没有指定格式时依次尝试的格式
+	timeLayouts = []string{
+		fields.DATETIME_FORMAT,
+		`2006-01-02 15:04:05`,
+		fields.DATETIME_LOCAL_FORMAT,
+		time.RFC3339,
+		fields.DATE_FORMAT,
+		fields.TIME_FORMAT,
+		fields.MONTH_FORMAT,
+		fields.WEEK_FORMAT,
+	}
+)
+
+// fieldByIndex 与 reflect.Value.FieldByIndex 相同，但会为途经的 nil 指针分配内存
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Bind 将客户端提交的数据绑定到模型（模型必须为非空指针）。
+// 如果表单使用了配置（config.Config），则按配置中的元素名称绑定，否则按结构体字段绑定。
+// 所有转换失败的字段都会被记录到验证错误中，并返回第一个错误。
+// 设置了选项查询函数（form_lookup 标签或元素的 lookup）的字段还会验证提交的值是否存在。
+// 设置了远程验证器（form_remote 标签或元素的 remote，见 RegisterRemoteValidator）的字段还会使用远程验证器验证提交的值。
+// richtext 和 markdown 字段提交的内容会先按照 HTML 过滤策略进行过滤。
+// 未提交的 switch 字段绑定为关闭（false 或 0）。
+// tags 字段提交的值以“,”拆分后绑定到切片，或者以“,”连接后绑定到字符串。
+// 设置了过滤器（form_filter 标签或元素的 filters）的字段会先依次执行过滤器（见 fields.RegisterFilter）。
+// 设置了输入掩码（form_mask 标签或元素的 mask）的字段会先去掉掩码中的字符。
+// 地址组合字段（address）按照提交的国家的地址格式验证必填的部分。
+// 设置了授权函数（见 SetAuthorizer）时，不可编辑的字段（包括结构体中 form_perm 标签限制的字段）提交的值会被丢弃。
+// 只绑定配置或结构体中的字段，静态（static）、按钮、只读（readonly）和禁用（disabled）的字段除外。
+// 严格模式下（见 SetStrictBind）提交了表单中不存在的字段时返回 UnexpectedFieldsError 并且不绑定任何值。
+func (f *Form) Bind(values url.Values, model ...interface{}) error {
+	m := f.Model
+	if len(model) > 0 && model[0] != nil {
+		m = model[0]
+	}
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidBindTarget
+	}
+	if f.strictBind {
+		if names := f.unexpectedKeys(values, rv.Type()); len(names) > 0 {
+			return &UnexpectedFieldsError{Names: names}
+		}
+	}
+	var firstErr error
+	onError := func(name string, err error) {
+		var (
+			rangeErr *DecimalRangeError
+			tagsErr  *TagsError
+		)
+		switch {
+		case errors.As(err, &rangeErr):
+			f.Validate().SetError(name, rangeErr.Message)
+		case errors.As(err, &tagsErr):
+			f.Validate().SetError(name, tagsErr.Message)
+		default:
+			f.Validate().SetError(name, ErrInvalidValue.Error())
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf(`%s: %w`, name, err)
+		}
+	}
+	onLookup := func(name string, lookup string, vals []string) {
+		if err := f.validLookup(name, lookup, vals); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	onRemote := func(name string, remote string, vals []string) {
+		if err := f.validRemote(name, remote, vals, values); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if f.config != nil && len(f.config.Elements) > 0 {
+		elements := f.config.GetElements()
+		languages := f.config.GetElementLanguages()
+		unwritable := f.unwritableNames()
+		for _, name := range f.config.GetNames() {
+			if unwritable[name] { // 丢弃不可编辑字段提交的值
+				continue
+			}
+			vals, ok := values[name]
+			ele := elements[name]
+			if !ok {
+				// 关闭的开关不会被提交
+				if ele == nil || ele.Type != common.SWITCH {
+					continue
+				}
+				vals = []string{`0`}
+			}
+			bf := bindFormat{Location: f.location}
+			if ele != nil {
+				var err error
+				if vals, err = fields.FilterValues(ele.Filters, vals); err != nil {
+					onError(name, err)
+					continue
+				}
+				switch {
+				case fields.IsNativeTimeType(ele.Type):
+					bf.Layout = fields.TimeFormat(ele.Type, ele.Attr(`step`))
+				case ele.Type == common.DATETIME:
+					bf.Layout = ele.Format
+				case fields.IsDecimalType(ele.Type):
+					bf.Decimal = newDecimalFormat(strconv.Itoa(ele.GetPrecision(fields.DefaultPrecision)), ele.Attr(`min`), ele.Attr(`max`))
+					lang, ok := languages[name]
+					if !ok {
+						lang = f.Lang()
+					}
+					bf.Numbers = fields.GetNumberFormat(lang)
+				case fields.IsEditorType(ele.Type):
+					vals = fields.SanitizeValues(ele.Type, ele.Policy, vals)
+				case ele.Type == common.TAGS:
+					bf.Tags = newTagsFormat(ele.Attr(`max`), ele.Attr(`maxlength`), choiceIDs(ele.Choices))
+					if len(ele.Provider) > 0 {
+						f.provideTags(bf.Tags, ele.Provider)
+					}
+				}
+				vals = fields.UnmaskValues(ele.Mask, vals)
+			}
+			parts := f.parseNameToStructFieldName(name)
+			if err := bindValue(rv, parts, vals, bf); err != nil {
+				onError(name, err)
+			} else if ele != nil {
+				if len(ele.Lookup) > 0 {
+					onLookup(name, ele.Lookup, vals)
+				}
+				if len(ele.Remote) > 0 {
+					onRemote(name, ele.Remote, vals)
+				}
+			}
+		}
+		for _, ele := range addressElements(f.config.Elements) {
+			if unwritable[ele.Name] {
+				continue
+			}
+			if err := f.validAddress(values, addressPartNames(ele)); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return ErrInvalidBindTarget
+	}
+	var (
+		addresses     []string
+		addressFields = map[string]map[string]string{}
+	)
+	for _, sf := range getStructPlan(rv.Type()).BindFields() {
+		if sf.Readonly || !f.structWritable(sf) {
+			continue
+		}
+		name := sf.Name
+		if f.nameFn != nil {
+			name = f.nameFn(name)
+		}
+		if len(sf.AddressPart) > 0 {
+			parts, ok := addressFields[sf.Address]
+			if !ok {
+				parts = map[string]string{}
+				addressFields[sf.Address] = parts
+				addresses = append(addresses, sf.Address)
+			}
+			parts[sf.AddressPart] = name
+		}
+		if fields.IndirectType(sf.Type).Kind() == reflect.Map {
+			keys, ok := values[name+`.key`]
+			if !ok {
+				continue
+			}
+			if err := setMap(fieldByIndex(rv, sf.Index), keys, values[name+`.value`], f.bindFormat(sf)); err != nil {
+				onError(sf.Name, err)
+			}
+			continue
+		}
+		vals, ok := values[name]
+		if !ok {
+			vals, ok = values[name+`[]`]
+		}
+		if !ok {
+			// 未勾选的复选框和关闭的开关不会被提交
+			switch {
+			case sf.Switch:
+				vals = []string{`0`}
+			case fields.IndirectType(sf.Type).Kind() == reflect.Bool:
+				vals = []string{`false`}
+			default:
+				continue
+			}
+		}
+		vals, err := fields.FilterValues(sf.Filters, vals)
+		if err != nil {
+			onError(sf.Name, err)
+			continue
+		}
+		if sf.Split && len(vals) == 1 {
+			vals = splitValues(vals[0])
+		}
+		if len(sf.Editor) > 0 {
+			vals = fields.SanitizeValues(sf.Editor, sf.Policy, vals)
+		}
+		vals = fields.UnmaskValues(sf.Mask, vals)
+		if err := setValue(fieldByIndex(rv, sf.Index), vals, f.bindFormat(sf)); err != nil {
+			onError(sf.Name, err)
+		} else {
+			if len(sf.Lookup) > 0 {
+				onLookup(sf.Name, sf.Lookup, vals)
+			}
+			if len(sf.Remote) > 0 {
+				onRemote(sf.Name, sf.Remote, vals)
+			}
+		}
+	}
+	for _, address := range addresses {
+		if err := f.validAddress(values, addressFields[address]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// UnexpectedFieldsError 严格模式下提交了表单中不存在的字段
+type UnexpectedFieldsError struct {
+	Names []string
+}
+
+func (e *UnexpectedFieldsError) Error() string {
+	return ErrUnexpectedField.Error() + `: ` + strings.Join(e.Names, `, `)
+}
+
+func (e *UnexpectedFieldsError) Unwrap() error {
+	return ErrUnexpectedField
+}
+
+// SetStrictBind 设置 Bind 是否使用严格模式：提交了表单中不存在的字段时返回错误，
+// ignoreKeys 为允许提交的其它字段（如 CSRF 令牌）
+func (f *Form) SetStrictBind(strict bool, ignoreKeys ...string) *Form {
+	f.strictBind = strict
+	f.strictBindIgnore = ignoreKeys
+	return f
+}
+
+// unexpectedKeys 返回提交的数据中表单不存在的字段名称（已排序）。
+// 表单中的字段包括生成的全部字段（包括按钮）、配置中的字段或者结构体中的字段
+func (f *Form) unexpectedKeys(values url.Values, t reflect.Type) []string {
+	known := map[string]struct{}{}
+	for _, key := range f.strictBindIgnore {
+		known[key] = struct{}{}
+	}
+	collectElementNames(f.FieldList, known)
+	if f.config != nil && len(f.config.Elements) > 0 {
+		for name := range f.config.GetElements() {
+			known[name] = struct{}{}
+		}
+	} else if t = fields.IndirectType(t); t.Kind() == reflect.Struct {
+		for _, sf := range getStructPlan(t).BindFields() {
+			name := sf.Name
+			if f.nameFn != nil {
+				name = f.nameFn(name)
+			}
+			for _, key := range []string{name, name + `[]`, name + `.key`, name + `.value`} {
+				known[key] = struct{}{}
+			}
+		}
+	}
+	var names []string
+	for key := range values {
+		if _, ok := known[key]; !ok {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectElementNames 收集元素（包括 fieldset 和 langset 的子元素）的名称
+func collectElementNames(elements []config.FormElement, names map[string]struct{}) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case *FieldSetType:
+			collectElementNames(v.FieldList, names)
+		case *LangSetType:
+			for _, language := range v.Languages {
+				collectElementNames(language.Fields(), names)
+			}
+		default:
+			if name := elem.Name(); len(name) > 0 {
+				names[name] = struct{}{}
+			}
+		}
+	}
+}
+
+// bindFormat 返回结构体字段的绑定格式
+func (f *Form) bindFormat(sf *structField) bindFormat {
+	bf := bindFormat{Layout: sf.Format, Location: f.location, Decimal: sf.Decimal, Tags: sf.Tags}
+	if sf.Decimal != nil {
+		bf.Numbers = fields.GetNumberFormat(f.Lang())
+	}
+	if sf.Tags != nil && len(sf.Tags.Provider) > 0 {
+		// 选项提供者的选项与上下文和语言有关，每次绑定时获取
+		tf := *sf.Tags
+		f.provideTags(&tf, tf.Provider)
+		bf.Tags = &tf
+	}
+	return bf
+}
+
+// provideTags 从选项提供者获取 tags 字段允许的值。获取失败时记录错误，提交的值都不被接受
+func (f *Form) provideTags(tf *tagsFormat, provider string) {
+	choices, err := fields.ProvideChoices(f.Context(), provider, f.Lang())
+	if err != nil {
+		tf.Err = err
+		return
+	}
+	tf.Allowed = choiceIDs(choices)
+	if tf.Allowed == nil {
+		tf.Allowed = map[string]struct{}{}
+	}
+}
+
+// bindValue 按照路径（parts）将值绑定到结构体、map或切片中，模型中不存在的路径会被忽略
+func bindValue(value reflect.Value, parts []string, vals []string, bf bindFormat) error {
+	for i, part := range parts {
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		switch value.Kind() {
+		case reflect.Struct:
+			field := value.FieldByName(com.Title(part))
+			if !field.IsValid() || !field.CanSet() {
+				return nil
+			}
+			value = field
+		case reflect.Map:
+			typ := value.Type()
+			if typ.Key().Kind() != reflect.String {
+				return nil
+			}
+			if value.IsNil() {
+				value.Set(reflect.MakeMap(typ))
+			}
+			key := reflect.ValueOf(part).Convert(typ.Key())
+			elem := reflect.New(typ.Elem()).Elem()
+			if old := value.MapIndex(key); old.IsValid() {
+				elem.Set(old)
+			}
+			if err := bindValue(elem, parts[i+1:], vals, bf); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, elem)
+			return nil
+		case reflect.Slice:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 {
+				return nil
+			}
+			if index >= value.Len() {
+				grown := reflect.MakeSlice(value.Type(), index+1, index+1)
+				reflect.Copy(grown, value)
+				value.Set(grown)
+			}
+			value = value.Index(index)
+		case reflect.Interface:
+			var elem reflect.Value
+			if value.IsNil() {
+				elem = reflect.ValueOf(map[string]interface{}{})
+			} else {
+				elem = reflect.New(value.Elem().Type()).Elem()
+				elem.Set(value.Elem())
+			}
+			if elem.Kind() != reflect.Map && elem.Kind() != reflect.Slice && elem.Kind() != reflect.Pointer {
+				return nil
+			}
+			if err := bindValue(elem, parts[i:], vals, bf); err != nil {
+				return err
+			}
+			value.Set(elem)
+			return nil
+		default:
+			return nil
+		}
+	}
+	return setValue(value, vals, bf)
+}
+
+// setValue 将提交的字符串值转换为目标类型并赋值
+func setValue(value reflect.Value, vals []string, bf bindFormat) error {
+	var s string
+	if len(vals) > 0 {
+		s = vals[0]
+	}
+	if value.Kind() == reflect.Pointer {
+		if len(s) == 0 && len(vals) < 2 {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setValue(value.Elem(), vals, bf)
+	}
+	if _, ok := fields.SQLNullType(value.Type()); ok {
+		// sql.NullString 等类型：空值表示 NULL
+		if len(s) == 0 && len(vals) < 2 {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		if err := setValue(value.Field(0), vals, bf); err != nil {
+			return err
+		}
+		value.Field(1).SetBool(true)
+		return nil
+	}
+	if bf.Tags != nil {
+		tags, err := bf.Tags.normalize(vals)
+		if err != nil {
+			return err
+		}
+		bf.Tags = nil
+		if kind := value.Kind(); kind == reflect.Interface || kind == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+			return setValue(value, tags, bf)
+		}
+		vals = []string{strings.Join(tags, fields.TagsSeparator)}
+		s = vals[0]
+	}
+	if bf.Decimal != nil && (value.Kind() != reflect.Slice || value.Type().Elem().Kind() == reflect.Uint8) {
+		var err error
+		if s, err = bf.Decimal.normalize(s, value.Kind(), bf.Numbers); err != nil {
+			return err
+		}
+	}
+	if value.Type() != timeType && value.CanAddr() {
+		if u, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			value.SetBytes([]byte(s))
+			return nil
+		}
+		list := reflect.MakeSlice(value.Type(), 0, len(vals))
+		for _, v := range vals {
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setValue(elem, []string{v}, bf); err != nil {
+				return err
+			}
+			list = reflect.Append(list, elem)
+		}
+		value.Set(list)
+		return nil
+	case reflect.Interface:
+		if len(vals) > 1 {
+			value.Set(reflect.ValueOf(vals))
+		} else {
+			value.Set(reflect.ValueOf(s))
+		}
+		return nil
+	}
+	return setScalar(value, s, bf)
+}
+
+// setMap 将键值对输入框提交的数据（键与值按顺序一一对应）绑定到 map，键为空的行会被忽略
+func setMap(value reflect.Value, keys []string, vals []string, bf bindFormat) error {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	typ := value.Type()
+	m := reflect.MakeMapWithSize(typ, len(keys))
+	for i, k := range keys {
+		k = strings.TrimSpace(k)
+		if len(k) == 0 {
+			continue
+		}
+		key := reflect.New(typ.Key()).Elem()
+		if err := setScalar(key, k, bindFormat{}); err != nil {
+			return err
+		}
+		var v string
+		if i < len(vals) {
+			v = vals[i]
+		}
+		elem := reflect.New(typ.Elem()).Elem()
+		if err := setValue(elem, []string{v}, bf); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, elem)
+	}
+	value.Set(m)
+	return nil
+}
+
+// splitValues 拆分以“,”分隔的多个值
+func splitValues(s string) []string {
+	parts := strings.Split(s, `,`)
+	vals := make([]string, 0, len(parts))
+	for _, v := range parts {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+func setScalar(value reflect.Value, s string, bf bindFormat) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		if len(s) == 0 {
+			value.SetBool(false)
+			return nil
+		}
+		switch strings.ToLower(s) {
+		case `on`, `yes`, `y`:
+			value.SetBool(true)
+			return nil
+		case `off`, `no`, `n`:
+			value.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(s) == 0 {
+			value.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			if len(bf.Layout) == 0 || value.Kind() != reflect.Int64 && value.Kind() != reflect.Int {
+				return err
+			}
+			// 使用时间格式的整数字段保存的是时间戳
+			t, err := parseTime(s, bf)
+			if err != nil {
+				return err
+			}
+			n = t.Unix()
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if len(s) == 0 {
+			value.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if len(s) == 0 {
+			value.SetFloat(0)
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(n)
+	case reflect.Struct:
+		if value.Type() != timeType {
+			return nil
+		}
+		if len(s) == 0 {
+			value.Set(reflect.Zero(timeType))
+			return nil
+		}
+		t, err := parseTime(s, bf)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+// bindFormat 绑定字段时使用的格式
+type bindFormat struct {
+	Layout   string         // 时间格式
+	Location *time.Location // 时区（为 nil 时使用 time.Local）
+	Decimal  *decimalFormat // money 和 decimal 字段的数值格式
+	Numbers  fields.NumberFormat
+	Tags     *tagsFormat // tags 字段的限制
+}
+
+// decimalFormat money 和 decimal 字段的小数位数和取值范围（最小单位，nil 表示不限制）
+type decimalFormat struct {
+	Precision int
+	Min       *int64
+	Max       *int64
+}
+
+// newDecimalFormat 根据规范格式（如“1234.50”）的标签值创建数值格式，无效的值会被忽略
+func newDecimalFormat(precision string, min string, max string) *decimalFormat {
+	df := &decimalFormat{}
+	df.Precision, _ = fields.DecimalPrecision(precision)
+	if n, err := fields.ParseDecimal(min, df.Precision, fields.CanonicalNumberFormat); err == nil && len(min) > 0 {
+		df.Min = &n
+	}
+	if n, err := fields.ParseDecimal(max, df.Precision, fields.CanonicalNumberFormat); err == nil && len(max) > 0 {
+		df.Max = &n
+	}
+	return df
+}
+
+// DecimalRangeError 提交的 money 或 decimal 值超出了取值范围
+type DecimalRangeError struct {
+	Message string
+}
+
+func (e *DecimalRangeError) Error() string {
+	return e.Message
+}
+
+// normalize 将本地化格式的数值转换为 kind 类型可以解析的格式：整数为最小单位（如分），其它类型为规范格式
+func (df *decimalFormat) normalize(s string, kind reflect.Kind, nf fields.NumberFormat) (string, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return ``, nil
+	}
+	n, err := fields.ParseDecimal(s, df.Precision, nf)
+	if err != nil {
+		return s, err
+	}
+	if df.Min != nil && n < *df.Min {
+		return s, &DecimalRangeError{Message: fmt.Sprintf(strings.Replace(validation.MessageTmpls[`Min`], `%d`, `%s`, 1), fields.FormatDecimal(*df.Min, df.Precision, nf))}
+	}
+	if df.Max != nil && n > *df.Max {
+		return s, &DecimalRangeError{Message: fmt.Sprintf(strings.Replace(validation.MessageTmpls[`Max`], `%d`, `%s`, 1), fields.FormatDecimal(*df.Max, df.Precision, nf))}
+	}
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatInt(n, 10), nil
+	}
+	return fields.DecimalString(n, df.Precision), nil
+}
+
+// tagsFormat tags 字段的数量和长度限制（0 表示不限制）以及允许的值（nil 表示不限制）
+type tagsFormat struct {
+	MaxCount  int
+	MaxLength int
+	Allowed   map[string]struct{}
+	Provider  string // 允许的值来自选项提供者
+	Err       error  // 选项提供者的错误
+}
+
+// newTagsFormat 根据标签值或元素属性创建 tags 字段的限制，无效的值会被忽略
+func newTagsFormat(maxCount string, maxLength string, allowed map[string]struct{}) *tagsFormat {
+	tf := &tagsFormat{Allowed: allowed}
+	tf.MaxCount, tf.MaxLength, _ = fields.ParseTagsLimit(maxCount, maxLength)
+	return tf
+}
+
+// choiceIDs 返回选项的值，没有选项时返回 nil
+func choiceIDs(choices []*config.Choice) map[string]struct{} {
+	if len(choices) == 0 {
+		return nil
+	}
+	ids := make(map[string]struct{}, len(choices))
+	for _, c := range choices {
+		if len(c.Option) > 0 {
+			ids[c.Option[0]] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// TagsError 提交的 tags 超出了数量或长度限制
+type TagsError struct {
+	Message string
+}
+
+func (e *TagsError) Error() string {
+	return e.Message
+}
+
+// normalize 拆分提交的 tags 并检查数量、长度和允许的值
+func (tf *tagsFormat) normalize(vals []string) ([]string, error) {
+	tags := fields.SplitTags(vals...)
+	if tf.Err != nil && len(tags) > 0 {
+		return nil, tf.Err
+	}
+	if tf.MaxCount > 0 && len(tags) > tf.MaxCount {
+		return nil, &TagsError{Message: fmt.Sprintf(validation.MessageTmpls[`MaxSize`], tf.MaxCount)}
+	}
+	for _, tag := range tags {
+		if tf.MaxLength > 0 && utf8.RuneCountInString(tag) > tf.MaxLength {
+			return nil, &TagsError{Message: fmt.Sprintf(validation.MessageTmpls[`MaxSize`], tf.MaxLength) + `: ` + tag}
+		}
+		if tf.Allowed != nil {
+			if _, ok := tf.Allowed[tag]; !ok {
+				return nil, fmt.Errorf(`tag not allowed: %s`, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+func parseTime(s string, bf bindFormat) (t time.Time, err error) {
+	if len(bf.Layout) > 0 {
+		return fields.ParseTime(s, bf.Layout, bf.Location)
+	}
+	for _, layout := range timeLayouts {
+		t, err = fields.ParseTime(s, layout, bf.Location)
+		if err == nil {
+			return
+		}
+	}
+	return
+}
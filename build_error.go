@@ -0,0 +1,138 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"log"
+	"strings"
+
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+)
+
+// BuildError 生成表单字段时发生的错误（如无效的标签值）
+type BuildError struct {
+	Field string // 字段名
+	Err   error
+}
+
+func (e *BuildError) Error() string {
+	return e.Field + `: ` + e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// BuildErrors 生成表单时收集到的全部错误
+type BuildErrors []*BuildError
+
+func (e BuildErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, `; `)
+}
+
+// Unwrap 用于 errors.Is 和 errors.As
+func (e BuildErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// addBuildError 记录生成字段时的错误，出错的字段会使用默认值而不会导致 panic。
+// 不在错误收集模式（ParseModelE 等）时还会输出日志。
+func (f *Form) addBuildError(field string, err error) {
+	if err == nil {
+		return
+	}
+	buildErr := &BuildError{Field: field, Err: err}
+	if !f.collectErrors {
+		log.Println(`[Form]`, buildErr)
+	}
+	f.buildErrors = append(f.buildErrors, buildErr)
+}
+
+// BuildErrors 返回生成表单（ParseModel、ParseFromConfig）时收集到的错误
+func (f *Form) BuildErrors() BuildErrors {
+	return f.buildErrors
+}
+
+// BuildError 返回生成表单时收集到的错误（没有错误时返回nil）
+func (f *Form) BuildError() error {
+	if len(f.buildErrors) == 0 {
+		return nil
+	}
+	return f.buildErrors
+}
+
+// ParseModelE 与 ParseModel 相同，但会返回生成字段时收集到的全部错误
+func (f *Form) ParseModelE(model ...interface{}) error {
+	f.buildErrors = nil
+	f.collectErrors = true
+	defer func() {
+		f.collectErrors = false
+	}()
+	f.ParseModel(model...)
+	f.checkWidgets(f.FieldList)
+	return f.BuildError()
+}
+
+// ParseFromConfigE 与 ParseFromConfig 相同，但会返回生成字段时收集到的全部错误（包括 Init 中无法展开的 subform 的错误）
+func (f *Form) ParseFromConfigE(insertErrors ...bool) error {
+	f.buildErrors = append(BuildErrors(nil), f.expandErrors...)
+	f.collectErrors = true
+	defer func() {
+		f.collectErrors = false
+	}()
+	f.ParseFromConfig(insertErrors...)
+	f.checkWidgets(f.FieldList)
+	return f.BuildError()
+}
+
+// checkWidgets 预先加载字段模板，记录无法解析的模板
+func (f *Form) checkWidgets(elements []config.FormElement) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case *fields.Field:
+			f.addBuildError(v.OriginalName(), v.WidgetError())
+		case *FieldSetType:
+			f.checkWidgets(v.FieldList)
+		case *LangSetType:
+			for _, language := range v.Languages {
+				f.checkWidgets(language.Fields())
+			}
+		}
+	}
+}
+
+// NewWithConfigFileE 与 NewWithConfigFile 相同，但在配置文件无效或生成字段出错时返回错误而不是 panic
+func NewWithConfigFileE(m interface{}, configJSONFile string) (*Form, error) {
+	config, err := UnmarshalFile(configJSONFile)
+	if err != nil {
+		return nil, err
+	}
+	form := NewWithConfig(config)
+	form.SetModel(m)
+	if err := form.ParseFromConfigE(); err != nil {
+		return form, err
+	}
+	return form, nil
+}
@@ -0,0 +1,36 @@
+package forms_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coscms/forms"
+	"github.com/coscms/forms/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type buildErrorModel struct {
+	Birthday time.Time `form_widget:"date" form_min:"2020-13-01" form_max:"2030-01-01"`
+	Content  string    `form_widget:"textarea" form_rows:"ten"`
+	Name     string    `form_params:"a=%zz"`
+}
+
+func TestParseModelE(t *testing.T) {
+	form := forms.New()
+	err := form.ParseModelE(&buildErrorModel{Name: `webx`})
+	var buildErrs forms.BuildErrors
+	assert.True(t, errors.As(err, &buildErrs))
+	if assert.Len(t, buildErrs, 3) {
+		assert.Equal(t, `Birthday`, buildErrs[0].Field)
+		assert.Equal(t, `Content`, buildErrs[1].Field)
+		assert.Equal(t, `Name`, buildErrs[2].Field)
+	}
+	// 出错的字段仍然会生成
+	assert.Equal(t, `2030-01-01`, form.Field(`Birthday`).Data()[`params`].(common.HTMLAttributes).Get(`max`))
+	assert.Equal(t, `webx`, form.Field(`Name`).Data()[`value`])
+	assert.NotEmpty(t, form.Render())
+
+	_, err = forms.NewWithConfigFileE(nil, `testdata/not-exists.json`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/validation"
+)
+
+// ClientRule 客户端验证脚本使用的一条验证规则。
+// 有 Pattern 的规则用正则表达式验证（Negate 为 true 时不能匹配），其它规则按照 Rule 验证：
+// required（不能为空）、min、max、range（数值范围）、minSize、maxSize 和 length（字符数）
+type ClientRule struct {
+	Rule    string        `json:"rule"`
+	Args    []interface{} `json:"args,omitempty"`
+	Pattern string        `json:"pattern,omitempty"` // JavaScript 正则表达式
+	Flags   string        `json:"flags,omitempty"`   // 正则表达式的标志（i、m、s）
+	Negate  bool          `json:"negate,omitempty"`
+	Message string        `json:"message"` // 已翻译的错误信息
+}
+
+// ClientField 客户端验证脚本中一个字段的过滤器和验证规则
+type ClientField struct {
+	Filters []string      `json:"filters,omitempty"`
+	Rules   []*ClientRule `json:"rules"`
+}
+
+// ClientFilters 客户端验证脚本支持的过滤器。使用了其它过滤器的字段只在服务端验证
+var ClientFilters = map[string]bool{
+	`trim`:     true,
+	`collapse`: true,
+	`lower`:    true,
+	`upper`:    true,
+	`email`:    true,
+	`nfc`:      true,
+	`stripctl`: true,
+}
+
+// 与 webx-top/validation 的验证器相同的正则表达式
+var clientPatterns = map[string]func() (pattern string, negate bool){
+	`alpha`:        func() (string, bool) { return `^[A-Za-z]*$`, false },
+	`numeric`:      func() (string, bool) { return `^[0-9]*$`, false },
+	`alphaNumeric`: func() (string, bool) { return `^[A-Za-z0-9]*$`, false },
+	`alphaDash`:    func() (string, bool) { return validation.DefaultRule.AlphaDash, true },
+	`email`:        func() (string, bool) { return validation.DefaultRule.Email, false },
+	`ip`:           func() (string, bool) { return validation.DefaultRule.IPv4, false },
+	`base64`:       func() (string, bool) { return validation.DefaultRule.Base64, false },
+	`mobile`:       func() (string, bool) { return validation.DefaultRule.Mobile, false },
+	`tel`:          func() (string, bool) { return validation.DefaultRule.Telephone, false },
+	`phone`: func() (string, bool) {
+		return `(?:` + validation.DefaultRule.Mobile + `)|(?:` + validation.DefaultRule.Telephone + `)`, false
+	},
+	`zipCode`: func() (string, bool) { return validation.DefaultRule.ZipCode, false },
+}
+
+var regexpFlags = regexp.MustCompile(`^\(\?([ims]+)\)`)
+
+// ParseClientRules 将 valid 规则（与服务端验证相同的语法，例如 required;minSize(3);match(/^\w+$/)）转换为客户端验证规则，
+// fn 用于翻译错误信息（nil 为 common.LabelFn）。自定义的验证函数（validation.AddCustomFunc）只在服务端验证
+func ParseClientRules(valid string, fn func(string) string) ([]*ClientRule, error) {
+	if fn == nil {
+		fn = common.LabelFn
+	}
+	var rules []*ClientRule
+	valid = strings.TrimSpace(valid)
+	if index := strings.Index(valid, `match(/`); index > -1 {
+		end := strings.LastIndex(valid, `/)`)
+		if end < index {
+			return nil, fmt.Errorf(`invalid match rule: %s`, valid)
+		}
+		pattern := valid[index+len(`match(/`) : end]
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+		rule := &ClientRule{Rule: `match`}
+		rule.setPattern(pattern)
+		rule.Message = fn(fmt.Sprintf(validation.MessageTmpls[`Match`], pattern))
+		rules = append(rules, rule)
+		valid = strings.TrimSpace(valid[:index]) + `;` + strings.TrimSpace(valid[end+len(`/)`):])
+	}
+	for _, part := range strings.Split(valid, `;`) {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		name := part
+		var params []string
+		if start := strings.Index(part, `(`); start > -1 {
+			end := strings.Index(part, `)`)
+			if end < start || start == 0 {
+				return nil, fmt.Errorf(`invalid valid function: %s`, part)
+			}
+			name = strings.TrimSpace(part[:start])
+			params = strings.Split(part[start+1:end], `,`)
+		}
+		name = strings.ToLower(name[:1]) + name[1:] // 与服务端相同，规则名称不区分首字母大小写
+		rule, err := parseClientRule(name, params)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			continue
+		}
+		tmpl := validation.MessageTmpls[strings.ToUpper(name[:1])+name[1:]]
+		rule.Message = fn(fmt.Sprintf(strings.ReplaceAll(tmpl, `%d`, `%v`), rule.Args...))
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseClientRule(name string, params []string) (*ClientRule, error) {
+	rule := &ClientRule{Rule: name}
+	var numParams int
+	switch name {
+	case `required`:
+	case `min`, `max`:
+		numParams = 1
+	case `range`:
+		numParams = 2
+	case `minSize`, `maxSize`, `length`:
+		numParams = 1
+	default:
+		patternFn, ok := clientPatterns[name]
+		if !ok { // 自定义的验证函数
+			return nil, nil
+		}
+		pattern, negate := patternFn()
+		rule.setPattern(pattern)
+		rule.Negate = negate
+	}
+	if len(params) != numParams {
+		return nil, fmt.Errorf(`%s require %d parameters`, name, numParams)
+	}
+	for _, param := range params {
+		param = strings.TrimSpace(param)
+		var (
+			arg interface{}
+			err error
+		)
+		switch name {
+		case `minSize`, `maxSize`, `length`:
+			arg, err = strconv.Atoi(param)
+		default:
+			arg, err = strconv.ParseFloat(param, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, name, err)
+		}
+		rule.Args = append(rule.Args, arg)
+	}
+	return rule, nil
+}
+
+// setPattern 设置正则表达式，开头的标志（例如 (?i)）转换为 JavaScript 正则表达式的标志
+func (r *ClientRule) setPattern(pattern string) {
+	if m := regexpFlags.FindStringSubmatch(pattern); len(m) > 1 {
+		r.Flags = m[1]
+		pattern = pattern[len(m[0]):]
+	}
+	r.Pattern = pattern
+}
+
+// SetClientValidate 设置是否输出客户端验证脚本（默认为配置中的 clientValidate）
+func (f *Form) SetClientValidate(on bool) *Form {
+	f.clientValidate = on
+	return f
+}
+
+// ClientValidate 是否输出客户端验证脚本
+func (f *Form) ClientValidate() bool {
+	return f.clientValidate || (f.config != nil && f.config.ClientValidate)
+}
+
+// ValidationManifest 返回客户端验证脚本使用的验证规则，键为字段的名称（input 的 name 属性）。
+// 错误信息经过表单的标签翻译函数翻译，禁用和只读的字段以及使用了客户端不支持的过滤器的字段不包括在内
+func (f *Form) ValidationManifest() map[string]*ClientField {
+	manifest := map[string]*ClientField{}
+	f.clientFields(f.FieldList, manifest)
+	return manifest
+}
+
+func (f *Form) clientFields(elements []config.FormElement, manifest map[string]*ClientField) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case *fields.Field:
+			valid, filters := v.ValidRule()
+			if len(valid) == 0 || v.HasTag(config.Disabled) || v.HasTag(config.Readonly) {
+				continue
+			}
+			if !clientSupportsFilters(filters) {
+				continue
+			}
+			rules, err := ParseClientRules(valid, f.labelFn)
+			if err != nil || len(rules) == 0 {
+				continue
+			}
+			manifest[v.CurrName] = &ClientField{Filters: filters, Rules: rules}
+		case *FieldSetType:
+			f.clientFields(v.FieldList, manifest)
+		case *LangSetType:
+			for _, language := range v.Languages {
+				f.clientFields(language.Fields(), manifest)
+			}
+		}
+	}
+}
+
+func clientSupportsFilters(filters []string) bool {
+	for _, name := range filters {
+		if !ClientFilters[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientValidateTemplate 返回当前主题的客户端验证脚本模板（validate.html）
+func (f *Form) ClientValidateTemplate() (*template.Template, error) {
+	tmpl := common.TmplDir(f.Theme) + `/` + f.Theme + `/validate.html`
+	return common.GetOrSetCachedTemplate(tmpl, func() (*template.Template, error) {
+		return common.ParseFiles(common.LookupPath(tmpl))
+	})
+}
+
+// renderClientValidate 输出客户端验证脚本和验证规则
+func (f *Form) renderClientValidate() template.HTML {
+	t, err := f.ClientValidateTemplate()
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	buf := bytes.NewBuffer(nil)
+	err = t.ExecuteTemplate(buf, `validate`, map[string]interface{}{
+		"rules":       f.ValidationManifest(),
+		"remoteField": RemoteFieldParam,
+	})
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return template.HTML(buf.String())
+}
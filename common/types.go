@@ -91,6 +91,16 @@ const (
 	TEXTAREA       = "textarea"
 	SELECT         = "select"
 	STATIC         = "static"
+	AUTOCOMPLETE   = "autocomplete"
+	MONEY          = "money"
+	DECIMAL        = "decimal"
+	RICHTEXT       = "richtext"
+	MARKDOWN       = "markdown"
+	SWITCH         = "switch"
+	RATING         = "rating"
+	TAGS           = "tags"
+	ADDRESS        = "address"
+	SUBFORM        = "subform"
 )
 
 func SetTmplDir(theme, tmplDir string) {
@@ -32,8 +32,14 @@ type Config struct {
 	Languages      []*Language            `json:"languages"`                // 表单多语言支持
 	Data           map[string]interface{} `json:"data,omitempty"`           // 额外数据
 	TrimNamePrefix string                 `json:"trimNamePrefix,omitempty"` // 去除字段名前缀
+	Mode           string                 `json:"mode,omitempty"`           // 显示模式，readonly 为只读模式（见 Form.RenderReadonly）
+	RemoteURL      string                 `json:"remoteURL,omitempty"`      // 远程验证地址（见 forms.RemoteHandler），设置了 remote 的元素通过 data-remote 属性输出此地址
+	ClientValidate bool                   `json:"clientValidate,omitempty"` // 是否输出客户端验证脚本（见 Form.ValidationManifest）
 }
 
+// ModeReadonly 只读模式，表单的每个元素都显示为格式化后的值
+const ModeReadonly = `readonly`
+
 func (c *Config) Merge(source *Config) *Config {
 	if len(c.ID) == 0 && len(source.ID) > 0 {
 		c.ID = source.ID
@@ -131,6 +137,15 @@ func (c *Config) Merge(source *Config) *Config {
 	if len(c.TrimNamePrefix) == 0 && len(source.TrimNamePrefix) > 0 {
 		c.TrimNamePrefix = source.TrimNamePrefix
 	}
+	if len(c.Mode) == 0 && len(source.Mode) > 0 {
+		c.Mode = source.Mode
+	}
+	if len(c.RemoteURL) == 0 && len(source.RemoteURL) > 0 {
+		c.RemoteURL = source.RemoteURL
+	}
+	if !c.ClientValidate && source.ClientValidate {
+		c.ClientValidate = true
+	}
 	return c
 }
 
@@ -172,18 +187,21 @@ func (c *Config) Clone() *Config {
 		languages[index] = value.Clone()
 	}
 	r := &Config{
-		ID:           c.ID,
-		Theme:        c.Theme,
-		Template:     c.Template,
-		Method:       c.Method,
-		Action:       c.Action,
-		Attributes:   make([][]string, len(c.Attributes)),
-		WithButtons:  c.WithButtons,
-		Buttons:      make([]string, len(c.Buttons)),
-		BtnsTemplate: c.BtnsTemplate,
-		Elements:     elements,
-		Languages:    languages,
-		Data:         map[string]interface{}{},
+		ID:             c.ID,
+		Theme:          c.Theme,
+		Template:       c.Template,
+		Method:         c.Method,
+		Action:         c.Action,
+		Attributes:     make([][]string, len(c.Attributes)),
+		WithButtons:    c.WithButtons,
+		Buttons:        make([]string, len(c.Buttons)),
+		BtnsTemplate:   c.BtnsTemplate,
+		Mode:           c.Mode,
+		RemoteURL:      c.RemoteURL,
+		ClientValidate: c.ClientValidate,
+		Elements:       elements,
+		Languages:      languages,
+		Data:           map[string]interface{}{},
 	}
 	copy(r.Buttons, c.Buttons)
 	for k, v := range c.Data {
@@ -204,7 +222,7 @@ func (c *Config) HasName(name string) bool {
 func (c *Config) hasName(name string, elements []*Element, languages []*Language) bool {
 	for _, elem := range elements {
 		if elem.Name == name {
-			return elem.Type != `langset` && elem.Type != `fieldset`
+			return elem.Type != `langset` && !elem.IsFieldSet()
 		}
 		if elem.Type == `langset` {
 			if c.hasName(name, elem.Elements, elem.Languages) {
@@ -212,7 +230,7 @@ func (c *Config) hasName(name string, elements []*Element, languages []*Language
 			}
 			continue
 		}
-		if elem.Type == `fieldset` {
+		if elem.IsFieldSet() {
 			if c.hasName(name, elem.Elements, languages) {
 				return true
 			}
@@ -230,10 +248,25 @@ func (c *Config) hasName(name string, elements []*Element, languages []*Language
 	return false
 }
 
+// GetNames 返回可以提交的字段名称（不包括 static、按钮、只读和禁用的元素，多语言元素的名称为各语言的名称）
 func (c *Config) GetNames() []string {
 	return getNames(c.Elements, c.Languages)
 }
 
+// GetElements 返回以名称为键的全部字段元素（不包含 langset、fieldset 和 address，多语言元素的键为各语言的名称）
+func (c *Config) GetElements() map[string]*Element {
+	r := map[string]*Element{}
+	getElements(c.Elements, c.Languages, r)
+	return r
+}
+
+// GetElementLanguages 返回多语言元素的名称及其语言 ID
+func (c *Config) GetElementLanguages() map[string]string {
+	r := map[string]string{}
+	getElementLanguages(c.Elements, c.Languages, r)
+	return r
+}
+
 func (c *Config) SetDefaultValue(fieldDefaultValue func(fieldName string) string) {
 	if fieldDefaultValue != nil {
 		setDefaultValue(c.Elements, c.Languages, fieldDefaultValue)
@@ -6,6 +6,12 @@ import (
 	"github.com/webx-top/com"
 )
 
+// subform 子元素的命名方式（Element.Naming）
+const (
+	NamingDot      = `dot`
+	NamingBrackets = `brackets`
+)
+
 type Element struct {
 	ID           string                 `json:"id"`
 	Type         string                 `json:"type"`
@@ -20,12 +26,39 @@ type Element struct {
 	Valid        string                 `json:"valid"`
 	Attributes   [][]string             `json:"attributes"`
 	Choices      []*Choice              `json:"choices"`
+	Provider     string                 `json:"provider,omitempty"`  // 选项提供者名称（见 fields.RegisterChoiceProvider），设置后忽略 Choices
+	URL          string                 `json:"url,omitempty"`       // autocomplete 的搜索网址
+	Lookup       string                 `json:"lookup,omitempty"`    // autocomplete 的选项查询函数名称（见 fields.RegisterChoiceLookup）
+	Currency     string                 `json:"currency,omitempty"`  // money 的货币符号
+	Precision    *int                   `json:"precision,omitempty"` // money 和 decimal 的小数位数（默认为2）
+	Policy       string                 `json:"policy,omitempty"`    // richtext 和 markdown 的 HTML 过滤策略名称（见 fields.RegisterSanitizePolicy）
+	Labels       []string               `json:"labels,omitempty"`    // switch 的开、关状态文本或 rating 每颗星的标题
+	Mask         string                 `json:"mask,omitempty"`      // text、tel 和 search 的输入掩码，如“(999) 999-9999”（9：数字，a：字母，*：字母或数字）
+	Config       string                 `json:"config,omitempty"`    // subform 引用的表单配置名称（见 forms.RegisterSubform）或配置文件路径
+	Naming       string                 `json:"naming,omitempty"`    // subform 子元素的命名方式：NamingDot（默认，如“contact.name”）或 NamingBrackets（如“contact[name]”）
+	Perm         string                 `json:"perm,omitempty"`      // 编辑此元素所需的权限（见 forms.Form.SetAuthorizer）
+	Filters      []string               `json:"filters,omitempty"`   // 验证和绑定前依次对提交的值执行的过滤器，如“trim”、“lower”（见 fields.RegisterFilter）
+	Remote       string                 `json:"remote,omitempty"`    // 远程验证器名称（见 forms.RegisterRemoteValidator），如用户名是否已被使用
 	Elements     []*Element             `json:"elements"`
 	Format       string                 `json:"format"`
 	Languages    []*Language            `json:"languages,omitempty"`
 	Data         map[string]interface{} `json:"data,omitempty"`
 }
 
+// Permission 返回编辑此元素所需的权限：Perm 或者 Data 中的 perm
+func (c *Element) Permission() string {
+	if len(c.Perm) > 0 {
+		return c.Perm
+	}
+	perm, _ := c.Data[`perm`].(string)
+	return perm
+}
+
+// IsSubmittable 是否接受此元素提交的值：static、按钮、只读和禁用的元素以及没有名称的元素除外
+func (c *Element) IsSubmittable() bool {
+	return len(c.Name) > 0 && c.Type != STATIC && !isButton(c.Type) && !c.HasAttr(Disabled, Readonly)
+}
+
 func (c *Element) GetNameInData() string {
 	if len(c.Data) == 0 {
 		return ``
@@ -71,6 +104,11 @@ func (c *Element) GetName() string {
 	return name
 }
 
+// IsFieldSet 是否为包含子元素的字段集（fieldset、由街道、城市等子元素组成的 address 或者嵌入其它表单配置的 subform）
+func (c *Element) IsFieldSet() bool {
+	return c.Type == `fieldset` || c.Type == ADDRESS || c.Type == SUBFORM
+}
+
 func (c *Element) GetStructFieldName() string {
 	if len(c.Name) == 0 {
 		return ``
@@ -114,6 +152,46 @@ func (c *Element) Merge(source *Element) *Element {
 	if len(c.Format) == 0 && len(source.Format) > 0 {
 		c.Format = source.Format
 	}
+	if len(c.Provider) == 0 && len(source.Provider) > 0 {
+		c.Provider = source.Provider
+	}
+	if len(c.URL) == 0 && len(source.URL) > 0 {
+		c.URL = source.URL
+	}
+	if len(c.Lookup) == 0 && len(source.Lookup) > 0 {
+		c.Lookup = source.Lookup
+	}
+	if len(c.Policy) == 0 && len(source.Policy) > 0 {
+		c.Policy = source.Policy
+	}
+	if len(c.Currency) == 0 && len(source.Currency) > 0 {
+		c.Currency = source.Currency
+	}
+	if c.Precision == nil && source.Precision != nil {
+		precision := *source.Precision
+		c.Precision = &precision
+	}
+	if len(c.Mask) == 0 && len(source.Mask) > 0 {
+		c.Mask = source.Mask
+	}
+	if len(c.Config) == 0 && len(source.Config) > 0 {
+		c.Config = source.Config
+	}
+	if len(c.Naming) == 0 && len(source.Naming) > 0 {
+		c.Naming = source.Naming
+	}
+	if len(c.Perm) == 0 && len(source.Perm) > 0 {
+		c.Perm = source.Perm
+	}
+	if len(c.Remote) == 0 && len(source.Remote) > 0 {
+		c.Remote = source.Remote
+	}
+	if len(c.Labels) == 0 && len(source.Labels) > 0 {
+		c.Labels = append([]string{}, source.Labels...)
+	}
+	if len(c.Filters) == 0 && len(source.Filters) > 0 {
+		c.Filters = append([]string{}, source.Filters...)
+	}
 	var found bool
 	for _, v := range source.Attributes {
 		if len(v) == 0 {
@@ -234,11 +312,31 @@ func (e *Element) Clone() *Element {
 		Valid:        e.Valid,
 		Attributes:   make([][]string, len(e.Attributes)),
 		Choices:      choices,
+		Provider:     e.Provider,
+		URL:          e.URL,
+		Lookup:       e.Lookup,
+		Currency:     e.Currency,
+		Policy:       e.Policy,
+		Mask:         e.Mask,
+		Config:       e.Config,
+		Naming:       e.Naming,
+		Perm:         e.Perm,
+		Remote:       e.Remote,
 		Elements:     elements,
 		Format:       e.Format,
 		Languages:    languages,
 		Data:         map[string]interface{}{},
 	}
+	if e.Precision != nil {
+		precision := *e.Precision
+		r.Precision = &precision
+	}
+	if len(e.Labels) > 0 {
+		r.Labels = append([]string{}, e.Labels...)
+	}
+	if len(e.Filters) > 0 {
+		r.Filters = append([]string{}, e.Filters...)
+	}
 	for k, v := range e.Data {
 		r.Data[k] = v
 	}
@@ -270,6 +368,24 @@ func (e *Element) HasAttr(attrs ...string) bool {
 	return false
 }
 
+// Attr 返回属性值（不存在时返回空字符串）
+func (e *Element) Attr(name string) string {
+	for _, v := range e.Attributes {
+		if len(v) == 2 && strings.EqualFold(v[0], name) {
+			return v[1]
+		}
+	}
+	return ``
+}
+
+// GetPrecision 返回 money 和 decimal 元素的小数位数，未设置时返回 def
+func (e *Element) GetPrecision(def int) int {
+	if e.Precision == nil {
+		return def
+	}
+	return *e.Precision
+}
+
 func (e *Element) AddElement(elements ...*Element) *Element {
 	e.Elements = append(e.Elements, elements...)
 	return e
@@ -327,5 +443,8 @@ func (e *Element) GetMultilingualText(recv *map[string]struct{}) {
 		if len(v.Option) == 2 && len(v.Option[1]) > 0 && !IsExistsKey(recv, v.Option[1]) && !com.StrIsNumeric(v.Option[1]) {
 			(*recv)[v.Option[1]] = struct{}{}
 		}
+		if len(v.Description) > 0 && !IsExistsKey(recv, v.Description) {
+			(*recv)[v.Description] = struct{}{}
+		}
 	}
 }
@@ -1,18 +1,33 @@
 package config
 
 type Choice struct {
-	Group   string   `json:"group"`
-	Option  []string `json:"option"` //["value","text"]
-	Checked bool     `json:"checked"`
+	Group       string            `json:"group"`
+	Option      []string          `json:"option"` //["value","text"]
+	Checked     bool              `json:"checked"`
+	Disabled    bool              `json:"disabled,omitempty"`
+	Description string            `json:"description,omitempty"` // 选项说明
+	Icon        string            `json:"icon,omitempty"`        // 图标 CSS 类名
+	Image       string            `json:"image,omitempty"`       // 图片网址（卡片式单选/多选框）
+	Data        map[string]string `json:"data,omitempty"`        // 输出为 data-* 属性
 }
 
 func (c *Choice) Clone() *Choice {
 	r := &Choice{
-		Group:   c.Group,
-		Option:  make([]string, len(c.Option)),
-		Checked: c.Checked,
+		Group:       c.Group,
+		Option:      make([]string, len(c.Option)),
+		Checked:     c.Checked,
+		Disabled:    c.Disabled,
+		Description: c.Description,
+		Icon:        c.Icon,
+		Image:       c.Image,
 	}
 	copy(r.Option, c.Option)
+	if c.Data != nil {
+		r.Data = make(map[string]string, len(c.Data))
+		for k, v := range c.Data {
+			r.Data[k] = v
+		}
+	}
 	return r
 }
 
@@ -37,5 +52,27 @@ func (c *Choice) Merge(source *Choice) *Choice {
 			found = false
 		}
 	}
+	if !c.Disabled && source.Disabled {
+		c.Disabled = source.Disabled
+	}
+	if len(c.Description) == 0 && len(source.Description) > 0 {
+		c.Description = source.Description
+	}
+	if len(c.Icon) == 0 && len(source.Icon) > 0 {
+		c.Icon = source.Icon
+	}
+	if len(c.Image) == 0 && len(source.Image) > 0 {
+		c.Image = source.Image
+	}
+	if source.Data != nil {
+		if c.Data == nil {
+			c.Data = map[string]string{}
+		}
+		for k, v := range source.Data {
+			if _, ok := c.Data[k]; !ok {
+				c.Data[k] = v
+			}
+		}
+	}
 	return c
 }
@@ -2,10 +2,16 @@ package config
 
 const (
 	STATIC   = "static"
+	ADDRESS  = "address"
+	SUBFORM  = "subform"
 	Disabled = "disabled"
 	Readonly = "readonly"
 )
 
+func isButton(typ string) bool {
+	return typ == `button` || typ == `submit` || typ == `reset`
+}
+
 func getNames(elements []*Element, languages []*Language) []string {
 	var names []string
 	for _, elem := range elements {
@@ -13,11 +19,11 @@ func getNames(elements []*Element, languages []*Language) []string {
 			names = append(names, getNames(elem.Elements, elem.Languages)...)
 			continue
 		}
-		if elem.Type == `fieldset` {
+		if elem.IsFieldSet() {
 			names = append(names, getNames(elem.Elements, languages)...)
 			continue
 		}
-		if len(elem.Name) > 0 && elem.Type != STATIC && !elem.HasAttr(Disabled, Readonly) {
+		if elem.IsSubmittable() {
 			if len(languages) == 0 {
 				names = append(names, elem.Name)
 			} else {
@@ -30,13 +36,55 @@ func getNames(elements []*Element, languages []*Language) []string {
 	return names
 }
 
+func getElements(elements []*Element, languages []*Language, r map[string]*Element) {
+	for _, elem := range elements {
+		if elem.Type == `langset` {
+			getElements(elem.Elements, elem.Languages, r)
+			continue
+		}
+		if elem.IsFieldSet() {
+			getElements(elem.Elements, languages, r)
+			continue
+		}
+		if len(elem.Name) == 0 {
+			continue
+		}
+		if len(languages) == 0 {
+			r[elem.Name] = elem
+			continue
+		}
+		for _, lang := range languages {
+			r[lang.Name(elem.Name)] = elem
+		}
+	}
+}
+
+func getElementLanguages(elements []*Element, languages []*Language, r map[string]string) {
+	for _, elem := range elements {
+		if elem.Type == `langset` {
+			getElementLanguages(elem.Elements, elem.Languages, r)
+			continue
+		}
+		if elem.IsFieldSet() {
+			getElementLanguages(elem.Elements, languages, r)
+			continue
+		}
+		if len(elem.Name) == 0 {
+			continue
+		}
+		for _, lang := range languages {
+			r[lang.Name(elem.Name)] = lang.ID
+		}
+	}
+}
+
 func setDefaultValue(elements []*Element, languages []*Language, fieldDefaultValue func(string) string) {
 	for _, elem := range elements {
 		if elem.Type == `langset` {
 			setDefaultValue(elem.Elements, elem.Languages, fieldDefaultValue)
 			continue
 		}
-		if elem.Type == `fieldset` {
+		if elem.IsFieldSet() {
 			setDefaultValue(elem.Elements, languages, fieldDefaultValue)
 			continue
 		}
@@ -64,7 +112,7 @@ func setValue(elements []*Element, languages []*Language, fieldValue func(string
 			setValue(elem.Elements, elem.Languages, fieldValue)
 			continue
 		}
-		if elem.Type == `fieldset` {
+		if elem.IsFieldSet() {
 			setValue(elem.Elements, languages, fieldValue)
 			continue
 		}
@@ -89,7 +137,7 @@ func getValue(elements []*Element, languages []*Language, fieldValue func(string
 			getValue(elem.Elements, elem.Languages, fieldValue)
 			continue
 		}
-		if elem.Type == `fieldset` {
+		if elem.IsFieldSet() {
 			getValue(elem.Elements, languages, fieldValue)
 			continue
 		}
@@ -113,7 +161,7 @@ func getValue(elements []*Element, languages []*Language, fieldValue func(string
 func getMultilingualText(elements []*Element, languages []*Language, recv *map[string]struct{}) {
 	for _, elem := range elements {
 		elem.GetMultilingualText(recv)
-		if elem.Type == `langset` || elem.Type == `fieldset` {
+		if elem.Type == `langset` || elem.IsFieldSet() {
 			getMultilingualText(elem.Elements, elem.Languages, recv)
 		}
 	}
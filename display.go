@@ -0,0 +1,168 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+)
+
+// 只读模式中元素的种类
+const (
+	DisplayField    = `field`
+	DisplayFieldSet = `fieldset`
+	DisplayLangSet  = `langset`
+)
+
+// DisplayElement 只读模式中显示的元素
+type DisplayElement struct {
+	Kind      string        `json:"kind" xml:"kind"` // field、fieldset 或 langset
+	Name      string        `json:"name" xml:"name"`
+	Label     string        `json:"label" xml:"label"`
+	Type      string        `json:"type,omitempty" xml:"type,omitempty"` // 字段类型
+	Value     template.HTML `json:"value,omitempty" xml:"value,omitempty"`
+	Multiline bool          `json:"multiline,omitempty" xml:"multiline,omitempty"` // 多行文本（textarea、markdown 和 richtext）
+	HelpText  string        `json:"helpText,omitempty" xml:"helpText,omitempty"`
+	Classes   string        `json:"classes,omitempty" xml:"classes,omitempty"`
+
+	Elements  []*DisplayElement  `json:"elements,omitempty" xml:"elements,omitempty"`   // fieldset 的子元素
+	Languages []*DisplayLanguage `json:"languages,omitempty" xml:"languages,omitempty"` // langset 的各语言
+}
+
+// DisplayLanguage 只读模式中 langset 的一种语言（一个标签页）
+type DisplayLanguage struct {
+	ID       string            `json:"id" xml:"id"`
+	Label    string            `json:"label" xml:"label"`
+	TabID    string            `json:"tabID" xml:"tabID"`
+	Elements []*DisplayElement `json:"elements" xml:"elements"`
+}
+
+// DisplayElements 返回表单中所有元素的只读显示数据（不包括隐藏字段和按钮）
+func (f *Form) DisplayElements() []*DisplayElement {
+	return f.displayElements(f.FieldList)
+}
+
+func (f *Form) displayElements(elements []config.FormElement) []*DisplayElement {
+	list := make([]*DisplayElement, 0, len(elements))
+	for _, elem := range elements {
+		if d := f.displayElement(elem); d != nil {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+func (f *Form) displayElement(elem config.FormElement) *DisplayElement {
+	switch v := elem.(type) {
+	case *fields.Field:
+		if !fields.IsDisplayed(v.Type) {
+			return nil
+		}
+		return &DisplayElement{
+			Kind:      DisplayField,
+			Name:      v.Name(),
+			Label:     v.Label,
+			Type:      v.Type,
+			Value:     v.DisplayHTML(f.labelFn),
+			Multiline: v.Type == common.TEXTAREA || v.Type == common.MARKDOWN || v.Type == common.RICHTEXT,
+			HelpText:  v.HelpText,
+			Classes:   v.Classes.String(),
+		}
+	case *FieldSetType:
+		return &DisplayElement{
+			Kind:     DisplayFieldSet,
+			Name:     v.CurrName,
+			Label:    v.Label,
+			HelpText: v.HelpText,
+			Classes:  v.Classes.String(),
+			Elements: f.displayElements(v.FieldList),
+		}
+	case *LangSetType:
+		uniqid := f.ID
+		if len(uniqid) == 0 {
+			uniqid = common.RandomString(5)
+		}
+		d := &DisplayElement{
+			Kind:      DisplayLangSet,
+			Name:      v.CurrName,
+			HelpText:  v.HelpText,
+			Languages: make([]*DisplayLanguage, len(v.Languages)),
+		}
+		for i, language := range v.Languages {
+			d.Languages[i] = &DisplayLanguage{
+				ID:       language.ID,
+				Label:    language.Label,
+				TabID:    `display_` + common.Slugify(v.CurrName) + `_` + language.ID + `_` + uniqid,
+				Elements: f.displayElements(language.Fields()),
+			}
+		}
+		return d
+	case fields.FieldInterface:
+		// 自定义的字段没有只读显示，按原样输出
+		return &DisplayElement{
+			Kind:  DisplayField,
+			Name:  v.OriginalName(),
+			Value: v.Render(),
+		}
+	}
+	return nil
+}
+
+// DisplayTemplate 返回当前主题的只读模式模板（display 目录）
+func (f *Form) DisplayTemplate() (*template.Template, error) {
+	dir := common.TmplDir(f.Theme) + `/` + f.Theme + `/display/`
+	files := []string{`form.html`, `field.html`, `fieldset.html`, `langset.html`}
+	return common.GetOrSetCachedTemplate(dir+`form.html`, func() (*template.Template, error) {
+		paths := make([]string, len(files))
+		for i, file := range files {
+			paths[i] = common.LookupPath(dir + file)
+		}
+		return common.ParseFiles(paths...)
+	})
+}
+
+func (f *Form) renderReadonly() string {
+	f.runBefore()
+	buf := bytes.NewBuffer(nil)
+	t, err := f.DisplayTemplate()
+	if err != nil {
+		return err.Error()
+	}
+	err = t.ExecuteTemplate(buf, `main`, map[string]interface{}{
+		"elements": f.DisplayElements(),
+		"id":       f.ID,
+		"classes":  f.Class,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// RenderReadonly 以只读模式输出表单：每个元素显示为格式化后的值（选项显示标签、日期按照格式显示、密码被遮盖等），
+// 配置的 mode 为 readonly 时 Render 也以只读模式输出
+func (f *Form) RenderReadonly() template.HTML {
+	return template.HTML(f.renderReadonly())
+}
+
+// IsReadonly 是否以只读模式输出
+func (f *Form) IsReadonly() bool {
+	return f.config != nil && f.config.Mode == config.ModeReadonly
+}
@@ -0,0 +1,168 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Parts of an address composite. They are the last segment of the names of its sub-inputs
+// (ex: "shipping.postalCode") and, title-cased, the fields of the nested struct it binds to (ex: Shipping.PostalCode).
+const (
+	AddressStreet     = `street`
+	AddressCity       = `city`
+	AddressRegion     = `region`
+	AddressPostalCode = `postalCode`
+	AddressCountry    = `country`
+)
+
+// AddressParts lists the parts of an address composite in their default order.
+var AddressParts = []string{AddressStreet, AddressCity, AddressRegion, AddressPostalCode, AddressCountry}
+
+// IsAddressPart reports whether name is a part of an address composite.
+func IsAddressPart(name string) bool {
+	for _, part := range AddressParts {
+		if part == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddressPart is an input of an address composite as displayed for a country.
+type AddressPart struct {
+	Name     string // one of AddressParts
+	Label    string // untranslated label, passed to the label function of the form
+	Required bool
+}
+
+// AddressFormat is the layout of the address composite for a country: only the listed parts are displayed, in this order.
+type AddressFormat struct {
+	Country string // ISO 3166-1 alpha-2 code, ex: "US"
+	Name    string // untranslated name of the country, the label of the country option
+	Parts   []AddressPart
+}
+
+// Part returns the part of the format with the given name.
+func (a *AddressFormat) Part(name string) (AddressPart, bool) {
+	for _, p := range a.Parts {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return AddressPart{}, false
+}
+
+// DefaultAddressFormat is used when no country is selected or the selected country has no registered format.
+var DefaultAddressFormat = &AddressFormat{
+	Parts: []AddressPart{
+		{Name: AddressStreet, Label: `Street address`, Required: true},
+		{Name: AddressCity, Label: `City`, Required: true},
+		{Name: AddressRegion, Label: `State / Province / Region`},
+		{Name: AddressPostalCode, Label: `Postal code`},
+		{Name: AddressCountry, Label: `Country`, Required: true},
+	},
+}
+
+var (
+	addressFormats = map[string]*AddressFormat{
+		`US`: {Country: `US`, Name: `United States`, Parts: []AddressPart{
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressRegion, Label: `State`, Required: true},
+			{Name: AddressPostalCode, Label: `ZIP code`, Required: true},
+			{Name: AddressCountry, Label: `Country`, Required: true},
+		}},
+		`CA`: {Country: `CA`, Name: `Canada`, Parts: []AddressPart{
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressRegion, Label: `Province`, Required: true},
+			{Name: AddressPostalCode, Label: `Postal code`, Required: true},
+			{Name: AddressCountry, Label: `Country`, Required: true},
+		}},
+		`GB`: {Country: `GB`, Name: `United Kingdom`, Parts: []AddressPart{
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+			{Name: AddressCity, Label: `Town / City`, Required: true},
+			{Name: AddressRegion, Label: `County`},
+			{Name: AddressPostalCode, Label: `Postcode`, Required: true},
+			{Name: AddressCountry, Label: `Country`, Required: true},
+		}},
+		`DE`: {Country: `DE`, Name: `Germany`, Parts: []AddressPart{
+			{Name: AddressStreet, Label: `Street and house number`, Required: true},
+			{Name: AddressPostalCode, Label: `Postal code`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressCountry, Label: `Country`, Required: true},
+		}},
+		`FR`: {Country: `FR`, Name: `France`, Parts: []AddressPart{
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+			{Name: AddressPostalCode, Label: `Postal code`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressCountry, Label: `Country`, Required: true},
+		}},
+		`CN`: {Country: `CN`, Name: `China`, Parts: []AddressPart{
+			{Name: AddressCountry, Label: `Country`, Required: true},
+			{Name: AddressRegion, Label: `Province`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+			{Name: AddressPostalCode, Label: `Postal code`},
+		}},
+		`JP`: {Country: `JP`, Name: `Japan`, Parts: []AddressPart{
+			{Name: AddressCountry, Label: `Country`, Required: true},
+			{Name: AddressPostalCode, Label: `Postal code`, Required: true},
+			{Name: AddressRegion, Label: `Prefecture`, Required: true},
+			{Name: AddressCity, Label: `City`, Required: true},
+			{Name: AddressStreet, Label: `Street address`, Required: true},
+		}},
+	}
+	addressFormatsMu sync.RWMutex
+)
+
+// RegisterAddressFormat adds or replaces the address format of a country.
+func RegisterAddressFormat(format *AddressFormat) {
+	addressFormatsMu.Lock()
+	addressFormats[strings.ToUpper(format.Country)] = format
+	addressFormatsMu.Unlock()
+}
+
+// GetAddressFormat returns the address format of a country (case-insensitive code), or DefaultAddressFormat.
+func GetAddressFormat(country string) *AddressFormat {
+	addressFormatsMu.RLock()
+	format, ok := addressFormats[strings.ToUpper(country)]
+	addressFormatsMu.RUnlock()
+	if !ok {
+		return DefaultAddressFormat
+	}
+	return format
+}
+
+// AddressCountries returns the countries with a registered address format, sorted by name. They are the options of the country part.
+func AddressCountries() []*AddressFormat {
+	addressFormatsMu.RLock()
+	list := make([]*AddressFormat, 0, len(addressFormats))
+	for _, format := range addressFormats {
+		list = append(list, format)
+	}
+	addressFormatsMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,126 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/coscms/forms/common"
+)
+
+// ErrChoiceNotFound is returned by LookupChoice when the submitted ID does not exist.
+var ErrChoiceNotFound = errors.New(`Choice not found`)
+
+// ChoiceLookup resolves the label of the choice identified by id for autocomplete (remote select) fields.
+// found is false if the choice does not exist; lang is the current language of the form.
+type ChoiceLookup func(ctx context.Context, lang string, id string) (label string, found bool, err error)
+
+var (
+	choiceLookups   = map[string]ChoiceLookup{}
+	choiceLookupsMu sync.RWMutex
+)
+
+// RegisterChoiceLookup registers a named choice lookup. Registering the same name again replaces the lookup.
+func RegisterChoiceLookup(name string, lookup ChoiceLookup) {
+	choiceLookupsMu.Lock()
+	choiceLookups[name] = lookup
+	choiceLookupsMu.Unlock()
+}
+
+// UnregisterChoiceLookup removes a named choice lookup.
+func UnregisterChoiceLookup(name string) {
+	choiceLookupsMu.Lock()
+	delete(choiceLookups, name)
+	choiceLookupsMu.Unlock()
+}
+
+// GetChoiceLookup returns the choice lookup registered with the given name.
+func GetChoiceLookup(name string) (ChoiceLookup, bool) {
+	choiceLookupsMu.RLock()
+	lookup, ok := choiceLookups[name]
+	choiceLookupsMu.RUnlock()
+	return lookup, ok
+}
+
+// LookupChoice calls the named choice lookup and returns the label of id.
+// The error wraps ErrChoiceNotFound if the choice does not exist.
+func LookupChoice(ctx context.Context, name string, lang string, id string) (string, error) {
+	lookup, ok := GetChoiceLookup(name)
+	if !ok {
+		return ``, fmt.Errorf(`choice lookup not found: %s`, name)
+	}
+	label, found, err := lookup(ctx, lang, id)
+	if err != nil {
+		return ``, err
+	}
+	if !found {
+		return ``, fmt.Errorf(`%w: %s`, ErrChoiceNotFound, id)
+	}
+	return label, nil
+}
+
+// AutocompleteField creates a remote select: a search input that queries url for suggestions and a hidden input,
+// identified by name, holding the ID of the selected choice.
+func AutocompleteField(name string, url string) *Field {
+	ret := FieldWithType(name, common.AUTOCOMPLETE)
+	ret.SetURL(url)
+	return ret
+}
+
+// AutocompleteFieldFromInstance creates and initializes an autocomplete field based on its name, the reference object instance and field number.
+// This method looks for "form_url" (the search endpoint) and "form_value" tags.
+func AutocompleteFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
+	ret := AutocompleteField(name, common.TagVal(t, fieldNo, "form_url"))
+	ret.SetValue(defaultValue(val, t, fieldNo, useFieldValue))
+	return ret
+}
+
+// SetURL sets the endpoint queried by an autocomplete field.
+func (f *Field) SetURL(url string) *Field {
+	f.Additional["url"] = url
+	return f
+}
+
+// URL returns the endpoint queried by an autocomplete field.
+func (f *Field) URL() string {
+	url, _ := f.Additional["url"].(string)
+	return url
+}
+
+// SetLookup sets the name of the ChoiceLookup used to resolve the label of the selected choice.
+func (f *Field) SetLookup(name string) *Field {
+	f.Additional["lookup"] = name
+	return f
+}
+
+// Lookup returns the name of the ChoiceLookup of an autocomplete field.
+func (f *Field) Lookup() string {
+	name, _ := f.Additional["lookup"].(string)
+	return name
+}
+
+// SetSelectedText sets the label displayed in the search input of an autocomplete field.
+func (f *Field) SetSelectedText(text string) *Field {
+	f.Additional["text"] = text
+	return f
+}
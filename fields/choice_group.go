@@ -0,0 +1,139 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ChoiceGroup is a group of select options rendered as <optgroup label="Label">; the "" group is not wrapped in an <optgroup>.
+type ChoiceGroup struct {
+	Label   string        `json:"label" xml:"label"`
+	Choices []InputChoice `json:"choices" xml:"choices"`
+}
+
+// ChoiceGroups is the ordered list of option groups of a select field. Groups are rendered in slice order.
+type ChoiceGroups []*ChoiceGroup
+
+// Group returns the group with the given label, nil if there is none.
+func (g ChoiceGroups) Group(label string) *ChoiceGroup {
+	for _, group := range g {
+		if group.Label == label {
+			return group
+		}
+	}
+	return nil
+}
+
+// Add appends choices to the group with the given label. The group is created at the end of the list if it does not exist.
+func (g *ChoiceGroups) Add(label string, choices ...InputChoice) *ChoiceGroup {
+	group := g.Group(label)
+	if group == nil {
+		group = &ChoiceGroup{Label: label, Choices: []InputChoice{}}
+		*g = append(*g, group)
+	}
+	group.Choices = append(group.Choices, choices...)
+	return group
+}
+
+// Clone returns a deep copy of the groups.
+func (g ChoiceGroups) Clone() ChoiceGroups {
+	c := make(ChoiceGroups, len(g))
+	for i, group := range g {
+		c[i] = &ChoiceGroup{Label: group.Label, Choices: cloneChoices(group.Choices)}
+	}
+	return c
+}
+
+// ChoiceMap returns the choices of a select field as a map[string][]InputChoice (group label => choices),
+// the type of Choices before ChoiceGroups. The order of the groups is lost; nil if the choices are not select choices.
+func (f *Field) ChoiceMap() map[string][]InputChoice {
+	groups, ok := ToChoiceGroups(f.Choices)
+	if !ok {
+		return nil
+	}
+	m := make(map[string][]InputChoice, len(groups))
+	for _, group := range groups {
+		m[group.Label] = append(m[group.Label], group.Choices...)
+	}
+	return m
+}
+
+// ToChoiceGroups converts select choices to ChoiceGroups. Accepted values are ChoiceGroups, map[string][]InputChoice
+// (groups are sorted by label, so the "" group comes first), []InputChoice and []string ([ID, Value, Checked]),
+// the last two being put into the "" group.
+func ToChoiceGroups(choices interface{}) (ChoiceGroups, bool) {
+	switch c := choices.(type) {
+	case ChoiceGroups:
+		return c, true
+	case []*ChoiceGroup:
+		return ChoiceGroups(c), true
+	case map[string][]InputChoice:
+		labels := make([]string, 0, len(c))
+		for label := range c {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		groups := make(ChoiceGroups, len(labels))
+		for i, label := range labels {
+			groups[i] = &ChoiceGroup{Label: label, Choices: c[label]}
+		}
+		return groups, true
+	case []InputChoice:
+		return ChoiceGroups{{Label: ``, Choices: c}}, true
+	case []string:
+		return ChoiceGroups{{Label: ``, Choices: stringsToChoices(c)}}, true
+	}
+	return nil, false
+}
+
+// stringsToChoices converts a [ID, Value, Checked] list to a single choice.
+func stringsToChoices(v []string) []InputChoice {
+	c := []InputChoice{
+		InputChoice{},
+	}
+	switch len(v) {
+	case 3:
+		c[0].Checked, _ = strconv.ParseBool(v[2])
+		fallthrough
+	case 2:
+		c[0].Val = v[1]
+		fallthrough
+	case 1:
+		c[0].ID = v[0]
+	}
+	return c
+}
+
+// cloneChoices returns a deep copy of the choices.
+func cloneChoices(choices []InputChoice) []InputChoice {
+	c := make([]InputChoice, len(choices))
+	copy(c, choices)
+	for i, v := range c {
+		if v.Data == nil {
+			continue
+		}
+		c[i].Data = make(map[string]string, len(v.Data))
+		for k, d := range v.Data {
+			c[i].Data[k] = d
+		}
+	}
+	return c
+}
@@ -0,0 +1,130 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+)
+
+// ChoiceProviderPrefix is the "form_choices" tag prefix that sources the choices from a registered ChoiceProvider,
+// ex: `form_choices:"provider:countries"`.
+const ChoiceProviderPrefix = `provider:`
+
+// ChoiceProvider returns the choices of a select, radio or checkbox field (ex: from a database lookup).
+// lang is the current language of the form. Choice.Option is a ["value","text"] pair; Choice.Group is only used by select fields.
+type ChoiceProvider func(ctx context.Context, lang string) ([]*config.Choice, error)
+
+var (
+	choiceProviders   = map[string]ChoiceProvider{}
+	choiceProvidersMu sync.RWMutex
+)
+
+// RegisterChoiceProvider registers a named choice provider. Registering the same name again replaces the provider.
+func RegisterChoiceProvider(name string, provider ChoiceProvider) {
+	choiceProvidersMu.Lock()
+	choiceProviders[name] = provider
+	choiceProvidersMu.Unlock()
+}
+
+// UnregisterChoiceProvider removes a named choice provider.
+func UnregisterChoiceProvider(name string) {
+	choiceProvidersMu.Lock()
+	delete(choiceProviders, name)
+	choiceProvidersMu.Unlock()
+}
+
+// GetChoiceProvider returns the choice provider registered with the given name.
+func GetChoiceProvider(name string) (ChoiceProvider, bool) {
+	choiceProvidersMu.RLock()
+	provider, ok := choiceProviders[name]
+	choiceProvidersMu.RUnlock()
+	return provider, ok
+}
+
+// ChoiceProviderName returns the provider name of a "form_choices" tag value like "provider:countries".
+func ChoiceProviderName(tag string) (string, bool) {
+	if !strings.HasPrefix(tag, ChoiceProviderPrefix) {
+		return ``, false
+	}
+	return strings.TrimPrefix(tag, ChoiceProviderPrefix), true
+}
+
+// ProvideChoices calls the named choice provider.
+func ProvideChoices(ctx context.Context, name string, lang string) ([]*config.Choice, error) {
+	provider, ok := GetChoiceProvider(name)
+	if !ok {
+		return nil, fmt.Errorf(`choice provider not found: %s`, name)
+	}
+	return provider(ctx, lang)
+}
+
+// SetChoicesFromConfig replaces the choices of a select, radio or checkbox field, or the <datalist> suggestions of a text-like or tags field
+// (whose Option may only contain the value). Option texts are translated by fn.
+func (f *Field) SetChoicesFromConfig(choices []*config.Choice, fn func(string) string) *Field {
+	if fn == nil {
+		fn = common.LabelFn
+	}
+	f.ChoiceKeys = map[string]ChoiceIndex{}
+	switch f.Type {
+	case common.SELECT:
+		chArr := ChoiceGroups{}
+		for _, v := range choices {
+			if len(v.Option) < 2 {
+				continue
+			}
+			chArr.Add(fn(v.Group), ChoiceFromConfig(v, fn))
+		}
+		f.SetChoices(chArr)
+	case common.RADIO, common.CHECKBOX:
+		chArr := make([]InputChoice, 0, len(choices))
+		for _, v := range choices {
+			if len(v.Option) < 2 {
+				continue
+			}
+			chArr = append(chArr, ChoiceFromConfig(v, fn))
+		}
+		f.SetChoices(chArr)
+		if f.Type == common.CHECKBOX && len(chArr) > 1 {
+			f.MultipleChoice()
+		}
+	default:
+		if !IsDatalistType(f.Type) && f.Type != common.TAGS {
+			break
+		}
+		chArr := make([]InputChoice, 0, len(choices))
+		for _, v := range choices {
+			if len(v.Option) == 0 {
+				continue
+			}
+			ic := ChoiceFromConfig(v, fn)
+			if len(v.Option) < 2 {
+				ic.Val = ic.ID
+			}
+			chArr = append(chArr, ic)
+		}
+		f.SetChoices(chArr)
+	}
+	return f
+}
@@ -0,0 +1,49 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+// SetValidRule stores the validation rule of the field ("valid" tag or property) and the filters that run before it,
+// used to generate the rules of the client-side validation script (see forms.Form.ValidationManifest).
+func (f *Field) SetValidRule(valid string, filters []string) *Field {
+	if len(valid) == 0 {
+		delete(f.Additional, "validRule")
+		delete(f.Additional, "validFilters")
+		return f
+	}
+	f.Additional["validRule"] = valid
+	f.Additional["validFilters"] = filters
+	return f
+}
+
+// ValidRule returns the validation rule of the field and the filters that run before it.
+func (f *Field) ValidRule() (string, []string) {
+	valid, _ := f.Additional["validRule"].(string)
+	filters, _ := f.Additional["validFilters"].([]string)
+	return valid, filters
+}
+
+// HasTag reports whether the field has the given tag (ex: "disabled", "readonly").
+func (f *Field) HasTag(tag string) bool {
+	for _, t := range f.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
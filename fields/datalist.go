@@ -0,0 +1,58 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// IsDatalistType reports whether choices of the field type are rendered as a <datalist> of suggestions (tick marks for range fields).
+func IsDatalistType(fieldType string) bool {
+	switch fieldType {
+	case common.TEXT, common.EMAIL, common.URL, common.TEL, common.NUMBER, common.RANGE, common.SEARCH, common.COLOR,
+		common.DATE, common.DATETIME, common.DATETIME_LOCAL, common.TIME, common.MONTH, common.WEEK:
+		return true
+	}
+	return false
+}
+
+// DatalistID returns the id of the <datalist> of the field: "<id>-datalist", or "datalist-<slugified name>" if the field has no id.
+// The current name is used so that the copies of a field in a langset get distinct ids.
+func (f *Field) DatalistID() string {
+	if len(f.ID) > 0 {
+		return f.ID + `-datalist`
+	}
+	return `datalist-` + common.Slugify(f.Name())
+}
+
+// SetDatalist sets the suggestions of a text-like field from a "form_datalist" tag value: values joined by "|",
+// ex: "red|green|blue". Use "provider:<name>" to source them from a ChoiceProvider.
+func (f *Field) SetDatalist(tag string) *Field {
+	values := strings.Split(tag, `|`)
+	choices := make([]InputChoice, 0, len(values))
+	for _, v := range values {
+		if len(v) > 0 {
+			choices = append(choices, InputChoice{ID: v, Val: v})
+		}
+	}
+	f.SetChoices(choices)
+	return f
+}
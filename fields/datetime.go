@@ -19,7 +19,11 @@
 package fields
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coscms/forms/common"
@@ -27,11 +31,110 @@ import (
 
 // Datetime format string to convert from time.Time objects to HTML fields and viceversa.
 const (
-	DATETIME_FORMAT = "2006-01-02 15:05"
-	DATE_FORMAT     = "2006-01-02"
-	TIME_FORMAT     = "15:05"
+	DATETIME_FORMAT       = "2006-01-02 15:04"
+	DATE_FORMAT           = "2006-01-02"
+	TIME_FORMAT           = "15:04"
+	DATETIME_LOCAL_FORMAT = "2006-01-02T15:04"
+	MONTH_FORMAT          = "2006-01"
+	// WEEK_FORMAT is the ISO 8601 week format ("2006-W01") of week inputs. It is not a Go layout:
+	// use FormatTime and ParseTime.
+	WEEK_FORMAT = "2006-W01"
 )
 
+// TimeFormat returns the HTML format of the value of a date/time field type ("" if fieldType is not a date/time type).
+// step is the "step" attribute in seconds: time and datetime-local values include seconds when step is not a multiple of 60.
+func TimeFormat(fieldType string, step ...string) string {
+	switch fieldType {
+	case common.DATE:
+		return DATE_FORMAT
+	case common.DATETIME:
+		return DATETIME_FORMAT
+	case common.MONTH:
+		return MONTH_FORMAT
+	case common.WEEK:
+		return WEEK_FORMAT
+	case common.TIME, common.DATETIME_LOCAL:
+		format := TIME_FORMAT
+		if fieldType == common.DATETIME_LOCAL {
+			format = DATETIME_LOCAL_FORMAT
+		}
+		if len(step) > 0 && len(step[0]) > 0 {
+			if n, err := strconv.ParseFloat(step[0], 64); err == nil && n > 0 && int64(n)%60 != 0 {
+				format += ":05"
+			}
+		}
+		return format
+	}
+	return ``
+}
+
+// IsNativeTimeType reports whether the browser sends the value of the field type in a fixed format (see TimeFormat),
+// so "form_format" does not apply to it.
+func IsNativeTimeType(fieldType string) bool {
+	switch fieldType {
+	case common.DATE, common.TIME, common.DATETIME_LOCAL, common.MONTH, common.WEEK:
+		return true
+	}
+	return false
+}
+
+// FormatTime formats t with format (WEEK_FORMAT is supported). t is converted to loc if loc is not nil,
+// otherwise it is formatted in its own zone.
+func FormatTime(t time.Time, format string, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	if format == WEEK_FORMAT {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf(`%04d-W%02d`, year, week)
+	}
+	return t.Format(format)
+}
+
+// ParseTime parses s with format (WEEK_FORMAT is supported, the result is the Monday of the week) in loc (time.Local if nil).
+// Time and datetime-local values are accepted with or without seconds.
+func ParseTime(s string, format string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	switch format {
+	case WEEK_FORMAT:
+		return parseWeek(s, loc)
+	case TIME_FORMAT, DATETIME_LOCAL_FORMAT:
+		if strings.Count(s, `:`) == 2 {
+			format += `:05`
+		}
+	case TIME_FORMAT + `:05`, DATETIME_LOCAL_FORMAT + `:05`:
+		if strings.Count(s, `:`) == 1 {
+			format = strings.TrimSuffix(format, `:05`)
+		}
+	}
+	return time.ParseInLocation(format, s, loc)
+}
+
+// parseWeek parses an ISO 8601 week ("2006-W01") and returns its Monday.
+func parseWeek(s string, loc *time.Location) (time.Time, error) {
+	yearStr, weekStr, ok := strings.Cut(s, `-W`)
+	if !ok {
+		return time.Time{}, fmt.Errorf(`invalid week %q`, s)
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(`invalid week %q`, s)
+	}
+	week, err := strconv.Atoi(weekStr)
+	if err != nil || week < 1 || week > 53 {
+		return time.Time{}, fmt.Errorf(`invalid week %q`, s)
+	}
+	// January 4th is always in the first ISO week
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+	if y, _ := monday.ISOWeek(); y != year {
+		return time.Time{}, fmt.Errorf(`invalid week %q`, s)
+	}
+	return monday, nil
+}
+
 func ConvertTime(v interface{}) (time.Time, bool) {
 	t, ok := v.(time.Time)
 	var isEmpty bool
@@ -69,106 +172,121 @@ func TimeField(name string) *Field {
 
 // DatetimeFieldFromInstance creates and initializes a datetime field based on its name, the reference object instance and field number.
 // This method looks for "form_min", "form_max" and "form_value" tags to add additional parameters to the field.
+// It panics if "form_min" or "form_max" does not match the date format, use DatetimeFieldFromInstanceE to get an error instead.
 func DatetimeFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
-	ret := DatetimeField(name)
-	dateFormat := DATETIME_FORMAT
-	if v := common.TagVal(t, fieldNo, "form_format"); len(v) > 0 {
-		dateFormat = v
-	}
-	ret.Format = dateFormat
-	// check tags
-	if v := common.TagVal(t, fieldNo, "form_min"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid date value (min) for field: " + name)
-		}
-		ret.SetParam("min", v)
-	}
-	if v := common.TagVal(t, fieldNo, "form_max"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid date value (max) for field: " + name)
-		}
-		ret.SetParam("max", v)
-	}
-
-	if useFieldValue {
-		if vt, isEmpty := ConvertTime(val.Field(fieldNo).Interface()); !vt.IsZero() {
-			ret.SetValue(vt.Format(dateFormat))
-		} else if isEmpty {
-			ret.SetValue(``)
-		}
-	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
-		ret.SetValue(v)
+	ret, err := DatetimeFieldFromInstanceE(val, t, fieldNo, name, useFieldValue)
+	if err != nil {
+		panic(err)
 	}
 	return ret
 }
 
+// DatetimeFieldFromInstanceE is like DatetimeFieldFromInstance but returns an error for invalid "form_min" or "form_max" tags.
+// The returned field is usable, the invalid bounds are ignored.
+func DatetimeFieldFromInstanceE(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) (*Field, error) {
+	ret := DatetimeField(name)
+	err := initTimeFieldFromInstance(ret, val, t, fieldNo, useFieldValue, nil)
+	return ret, err
+}
+
 // DateFieldFromInstance creates and initializes a date field based on its name, the reference object instance and field number.
 // This method looks for "form_min", "form_max" and "form_value" tags to add additional parameters to the field.
+// It panics if "form_min" or "form_max" does not match the date format, use DateFieldFromInstanceE to get an error instead.
 func DateFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
-	ret := DateField(name)
-	dateFormat := DATE_FORMAT
-	if v := common.TagVal(t, fieldNo, "form_format"); len(v) > 0 {
-		dateFormat = v
-	}
-	ret.Format = dateFormat
-	// check tags
-	if v := common.TagVal(t, fieldNo, "form_min"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid date value (min) for field: " + name)
-		}
-		ret.SetParam("min", v)
-	}
-	if v := common.TagVal(t, fieldNo, "form_max"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid date value (max) for field: " + name)
-		}
-		ret.SetParam("max", v)
-	}
-
-	if useFieldValue {
-		if vt, isEmpty := ConvertTime(val.Field(fieldNo).Interface()); !vt.IsZero() {
-			ret.SetValue(vt.Format(dateFormat))
-		} else if isEmpty {
-			ret.SetValue(``)
-		}
-	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
-		ret.SetValue(v)
+	ret, err := DateFieldFromInstanceE(val, t, fieldNo, name, useFieldValue)
+	if err != nil {
+		panic(err)
 	}
 	return ret
 }
 
+// DateFieldFromInstanceE is like DateFieldFromInstance but returns an error for invalid "form_min" or "form_max" tags.
+// The returned field is usable, the invalid bounds are ignored.
+func DateFieldFromInstanceE(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) (*Field, error) {
+	ret := DateField(name)
+	err := initTimeFieldFromInstance(ret, val, t, fieldNo, useFieldValue, nil)
+	return ret, err
+}
+
 // TimeFieldFromInstance creates and initializes a time field based on its name, the reference object instance and field number.
 // This method looks for "form_min", "form_max" and "form_value" tags to add additional parameters to the field.
+// It panics if "form_min" or "form_max" does not match the time format, use TimeFieldFromInstanceE to get an error instead.
 func TimeFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
+	ret, err := TimeFieldFromInstanceE(val, t, fieldNo, name, useFieldValue)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// TimeFieldFromInstanceE is like TimeFieldFromInstance but returns an error for invalid "form_min" or "form_max" tags.
+// The returned field is usable, the invalid bounds are ignored.
+func TimeFieldFromInstanceE(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) (*Field, error) {
 	ret := TimeField(name)
-	dateFormat := TIME_FORMAT
+	err := initTimeFieldFromInstance(ret, val, t, fieldNo, useFieldValue, nil)
+	return ret, err
+}
+
+// TemporalFieldFromInstanceE creates and initializes a date/time field of the given type (date, datetime, datetime-local, time,
+// month or week) based on its name, the reference object instance and field number. The value is converted to loc unless loc is nil.
+// The value is rendered in the HTML format of the type (see TimeFormat); "form_format" is the format of the "form_min", "form_max"
+// and "form_value" tags (and the render format of datetime fields). "form_step" sets the step attribute.
+// The returned field is usable, invalid tags are ignored and returned as an error.
+func TemporalFieldFromInstanceE(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, fieldType string, loc *time.Location) (*Field, error) {
+	ret := FieldWithType(name, fieldType)
+	err := initTimeFieldFromInstance(ret, val, t, fieldNo, useFieldValue, loc)
+	return ret, err
+}
+
+func initTimeFieldFromInstance(ret *Field, val reflect.Value, t reflect.Type, fieldNo int, useFieldValue bool, loc *time.Location) error {
+	step := common.TagVal(t, fieldNo, "form_step")
+	dateFormat := TimeFormat(ret.Type, step)
+	tagFormat := dateFormat
 	if v := common.TagVal(t, fieldNo, "form_format"); len(v) > 0 {
-		dateFormat = v
+		tagFormat = v
+		if !IsNativeTimeType(ret.Type) {
+			dateFormat = v
+		}
+		ret.SetDisplayFormat(v)
 	}
 	ret.Format = dateFormat
 	// check tags
-	if v := common.TagVal(t, fieldNo, "form_min"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid time value (min) for field: " + name)
+	var errs []error
+	if len(step) > 0 {
+		if _, err := strconv.ParseFloat(step, 64); err != nil && step != "any" {
+			errs = append(errs, fmt.Errorf("invalid %s step %q for field %s", ret.Type, step, ret.OriginalName()))
+		} else {
+			ret.SetParam("step", step)
 		}
-		ret.SetParam("min", v)
 	}
-	if v := common.TagVal(t, fieldNo, "form_max"); len(v) > 0 {
-		if !validateDateformat(v, dateFormat) {
-			panic("Invalid time value (max) for field: " + name)
+	for _, key := range []string{"min", "max"} {
+		v := common.TagVal(t, fieldNo, "form_"+key)
+		if len(v) == 0 {
+			continue
+		}
+		tm, err := ParseTime(v, tagFormat, loc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s value (%s) %q for field %s, expected format %q", ret.Type, key, v, ret.OriginalName(), tagFormat))
+			continue
 		}
-		ret.SetParam("max", v)
+		ret.SetParam(key, FormatTime(tm, dateFormat, loc))
 	}
 	if useFieldValue {
-		if v, isEmpty := ConvertTime(val.Field(fieldNo).Interface()); !v.IsZero() {
-			ret.SetValue(v.Format(dateFormat))
+		value := instanceValue(val, fieldNo)
+		if sv, ok := value.(string); ok {
+			ret.SetValue(sv)
+		} else if vt, isEmpty := ConvertTime(value); !vt.IsZero() {
+			ret.SetValue(FormatTime(vt, dateFormat, loc))
 		} else if isEmpty {
 			ret.SetValue(``)
 		}
 	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
+		if tm, err := ParseTime(v, tagFormat, loc); err == nil {
+			v = FormatTime(tm, dateFormat, loc)
+		}
 		ret.SetValue(v)
 	}
-	return ret
+	return errors.Join(errs...)
 }
 
 func validateDateformat(v string, format string) bool {
@@ -0,0 +1,330 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/coscms/forms/common"
+)
+
+// DefaultPrecision is the number of fraction digits of money and decimal fields without "form_precision".
+const DefaultPrecision = 2
+
+// NumberFormat describes how decimal numbers are written in a language.
+type NumberFormat struct {
+	Decimal       string // decimal separator
+	Thousands     string // thousands separator, "" disables grouping
+	CurrencyAfter bool   // the currency symbol follows the amount (ex: "12,50 €")
+}
+
+// CanonicalNumberFormat is the format of decimal values in models, tags and configuration files (ex: "1234.50").
+var CanonicalNumberFormat = NumberFormat{Decimal: `.`}
+
+var (
+	defaultNumberFormat = NumberFormat{Decimal: `.`, Thousands: `,`}
+	numberFormats       = map[string]NumberFormat{}
+	numberFormatsMu     sync.RWMutex
+)
+
+// RegisterNumberFormat registers the number format of a language (a config.Language ID such as "de" or "de-CH").
+// Registering "" replaces the default format ("1,234.50").
+func RegisterNumberFormat(lang string, nf NumberFormat) {
+	numberFormatsMu.Lock()
+	if len(lang) == 0 {
+		defaultNumberFormat = nf
+	} else {
+		numberFormats[strings.ToLower(lang)] = nf
+	}
+	numberFormatsMu.Unlock()
+}
+
+// GetNumberFormat returns the number format of a language. "de-CH" falls back on "de", then on the default format.
+func GetNumberFormat(lang string) NumberFormat {
+	lang = strings.ToLower(lang)
+	numberFormatsMu.RLock()
+	defer numberFormatsMu.RUnlock()
+	for len(lang) > 0 {
+		if nf, ok := numberFormats[lang]; ok {
+			return nf
+		}
+		pos := strings.LastIndexAny(lang, `-_`)
+		if pos < 0 {
+			break
+		}
+		lang = lang[:pos]
+	}
+	return defaultNumberFormat
+}
+
+// FormatDecimal formats a number of minor units (ex: cents) with precision fraction digits.
+func FormatDecimal(minor int64, precision int, nf NumberFormat) string {
+	s := strconv.FormatUint(absInt64(minor), 10)
+	if precision > 0 {
+		if len(s) <= precision {
+			s = strings.Repeat(`0`, precision-len(s)+1) + s
+		}
+	}
+	intPart, fracPart := s[:len(s)-precision], s[len(s)-precision:]
+	if len(nf.Thousands) > 0 && len(intPart) > 3 {
+		var b strings.Builder
+		head := len(intPart) % 3
+		if head > 0 {
+			b.WriteString(intPart[:head])
+		}
+		for i := head; i < len(intPart); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(nf.Thousands)
+			}
+			b.WriteString(intPart[i : i+3])
+		}
+		intPart = b.String()
+	}
+	if minor < 0 {
+		intPart = `-` + intPart
+	}
+	if precision <= 0 {
+		return intPart
+	}
+	return intPart + nf.Decimal + fracPart
+}
+
+// DecimalString formats a number of minor units in the canonical format (ex: "1234.50").
+func DecimalString(minor int64, precision int) string {
+	return FormatDecimal(minor, precision, CanonicalNumberFormat)
+}
+
+// ParseDecimal parses a number written in the nf format and returns it in minor units.
+// Spaces and thousands separators are ignored; more than precision fraction digits is an error.
+func ParseDecimal(s string, precision int, nf NumberFormat) (int64, error) {
+	orig := s
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f':
+			return -1
+		}
+		return r
+	}, s)
+	if len(nf.Thousands) > 0 && nf.Thousands != nf.Decimal {
+		s = strings.ReplaceAll(s, nf.Thousands, ``)
+	}
+	decimal := nf.Decimal
+	if len(decimal) == 0 {
+		decimal = `.`
+	}
+	var negative bool
+	switch {
+	case strings.HasPrefix(s, `-`):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, `+`):
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, decimal)
+	if len(intPart) == 0 && len(fracPart) == 0 || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf(`invalid decimal number %q`, orig)
+	}
+	if len(fracPart) > precision {
+		return 0, fmt.Errorf(`decimal number %q has more than %d fraction digits`, orig, precision)
+	}
+	digits := strings.TrimLeft(intPart+fracPart+strings.Repeat(`0`, precision-len(fracPart)), `0`)
+	if len(digits) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(`decimal number %q is out of range`, orig)
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// DecimalToMinor converts a model value to minor units: integers are minor units, floats are rounded
+// to precision fraction digits and other values (strings, decimal types implementing fmt.Stringer) are parsed
+// in the canonical format.
+func DecimalToMinor(v interface{}, precision int) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf(`decimal number %d is out of range`, u)
+		}
+		return int64(u), nil
+	case reflect.Float32, reflect.Float64:
+		return ParseDecimal(strconv.FormatFloat(rv.Float(), 'f', precision, 64), precision, CanonicalNumberFormat)
+	case reflect.String:
+		return ParseDecimal(rv.String(), precision, CanonicalNumberFormat)
+	}
+	return ParseDecimal(fmt.Sprint(v), precision, CanonicalNumberFormat)
+}
+
+// MoneyField creates a money field with the given name, number of fraction digits and currency symbol.
+func MoneyField(name string, precision int, currency string) *Field {
+	ret := FieldWithType(name, common.MONEY)
+	ret.SetPrecision(precision)
+	ret.SetCurrency(currency)
+	return ret
+}
+
+// DecimalField creates a decimal number field with the given name and number of fraction digits.
+func DecimalField(name string, precision int) *Field {
+	ret := FieldWithType(name, common.DECIMAL)
+	ret.SetPrecision(precision)
+	return ret
+}
+
+// IsDecimalType reports whether the field type holds fixed-point numbers (money and decimal).
+func IsDecimalType(fieldType string) bool {
+	return fieldType == common.MONEY || fieldType == common.DECIMAL
+}
+
+// DecimalFieldFromInstance creates and initializes a money or decimal field based on its name, the reference object instance and field number.
+// This method looks for "form_precision", "form_currency", "form_min", "form_max" and "form_value" tags; bounds and values
+// are written in the canonical format ("1234.50"). Integer model fields hold minor units (ex: cents).
+// The returned field is usable, invalid tags are ignored and returned as an error.
+func DecimalFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, fieldType string) (*Field, error) {
+	ret := FieldWithType(name, fieldType)
+	precision, err := DecimalPrecision(common.TagVal(t, fieldNo, "form_precision"))
+	ret.SetPrecision(precision)
+	if v := common.TagVal(t, fieldNo, "form_currency"); len(v) > 0 {
+		ret.SetCurrency(v)
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid %s precision for field %s: %w", fieldType, ret.OriginalName(), err))
+	}
+	for _, key := range []string{"min", "max"} {
+		v := common.TagVal(t, fieldNo, "form_"+key)
+		if len(v) == 0 {
+			continue
+		}
+		n, err := ParseDecimal(v, precision, CanonicalNumberFormat)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s value (%s) for field %s: %w", fieldType, key, ret.OriginalName(), err))
+			continue
+		}
+		ret.SetParam(key, DecimalString(n, precision))
+	}
+	if useFieldValue {
+		if v := instanceValue(val, fieldNo); v != nil {
+			if n, err := DecimalToMinor(v, precision); err == nil {
+				ret.SetValue(DecimalString(n, precision))
+			} else {
+				ret.SetValue(fmt.Sprint(v))
+			}
+		}
+	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
+		ret.SetValue(v)
+	}
+	return ret, errors.Join(errs...)
+}
+
+// DecimalPrecision parses a "form_precision" tag value, "" is DefaultPrecision.
+func DecimalPrecision(s string) (int, error) {
+	if len(s) == 0 {
+		return DefaultPrecision, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 18 {
+		return DefaultPrecision, fmt.Errorf(`invalid precision %q, expected a number between 0 and 18`, s)
+	}
+	return n, nil
+}
+
+// SetPrecision sets the number of fraction digits of a money or decimal field.
+func (f *Field) SetPrecision(precision int) *Field {
+	f.Additional["precision"] = precision
+	return f
+}
+
+// Precision returns the number of fraction digits of a money or decimal field.
+func (f *Field) Precision() int {
+	if precision, ok := f.Additional["precision"].(int); ok {
+		return precision
+	}
+	return DefaultPrecision
+}
+
+// SetCurrency sets the currency symbol displayed next to the amount of a money field.
+func (f *Field) SetCurrency(currency string) *Field {
+	f.Additional["currency"] = currency
+	return f
+}
+
+// Currency returns the currency symbol of a money field.
+func (f *Field) Currency() string {
+	currency, _ := f.Additional["currency"].(string)
+	return currency
+}
+
+// SetLocale sets the language used to format the value of a money or decimal field when the field has no language
+// (fields of a langset use the language of their set).
+func (f *Field) SetLocale(lang string) *Field {
+	f.Additional["locale"] = lang
+	return f
+}
+
+// NumberFormat returns the number format used to display the value of the field.
+func (f *Field) NumberFormat() NumberFormat {
+	lang := f.Language
+	if len(lang) == 0 {
+		lang, _ = f.Additional["locale"].(string)
+	}
+	return GetNumberFormat(lang)
+}
+
+// displayDecimal formats the canonical value of a money or decimal field for display, invalid values are returned as is.
+func (f *Field) displayDecimal() string {
+	if len(f.Value) == 0 {
+		return f.Value
+	}
+	precision := f.Precision()
+	n, err := ParseDecimal(f.Value, precision, CanonicalNumberFormat)
+	if err != nil {
+		return f.Value
+	}
+	return FormatDecimal(n, precision, f.NumberFormat())
+}
@@ -0,0 +1,181 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// DisplayPasswordMask replaces the value of non-empty password fields in readonly mode.
+var DisplayPasswordMask = `••••••••`
+
+// IsDisplayed reports whether a field of the given type is shown in readonly mode (hidden inputs and buttons are not).
+func IsDisplayed(fieldType string) bool {
+	switch fieldType {
+	case common.HIDDEN, common.BUTTON, common.SUBMIT, common.RESET:
+		return false
+	}
+	return true
+}
+
+// SetDisplayFormat sets the time format of the value of a date/time field in readonly mode. Browsers always send
+// date, time, month and week values in a fixed format (see TimeFormat), this is the format ("form_format") used to show them.
+func (f *Field) SetDisplayFormat(format string) *Field {
+	f.Additional["displayFormat"] = format
+	return f
+}
+
+// DisplayFormat returns the time format of the value of a date/time field in readonly mode.
+func (f *Field) DisplayFormat() string {
+	if format, ok := f.Additional["displayFormat"].(string); ok && len(format) > 0 {
+		return format
+	}
+	return f.Format
+}
+
+// DisplayValue returns the value of the field formatted for readonly (display) mode: labels of the selected choices,
+// dates in the display format, "Yes" or "No" for single checkboxes and switches, a mask for passwords, etc.
+// fn translates the texts added by this method (nil is common.LabelFn).
+func (f *Field) DisplayValue(fn func(string) string) string {
+	if fn == nil {
+		fn = common.LabelFn
+	}
+	switch f.Type {
+	case common.PASSWORD:
+		if len(f.Value) == 0 {
+			return ``
+		}
+		return DisplayPasswordMask
+	case common.SWITCH:
+		return displayBool(IsChecked(f.Value), fn)
+	case common.CHECKBOX, common.RADIO:
+		choices, _ := f.Choices.([]InputChoice)
+		if f.Type == common.CHECKBOX && len(choices) == 1 && len(choices[0].Val) == 0 {
+			// a single checkbox of a bool field
+			return displayBool(choices[0].Checked, fn)
+		}
+		return checkedLabels(choices)
+	case common.SELECT:
+		groups, _ := f.Choices.(ChoiceGroups)
+		var labels []string
+		for _, group := range groups {
+			if s := checkedLabels(group.Choices); len(s) > 0 {
+				labels = append(labels, s)
+			}
+		}
+		return strings.Join(labels, `, `)
+	case common.TAGS:
+		tags := f.TagValues()
+		choices, _ := f.Choices.([]InputChoice)
+		for i, tag := range tags {
+			tags[i] = choiceLabel(choices, tag)
+		}
+		return strings.Join(tags, `, `)
+	case common.RANGE:
+		choices, _ := f.Choices.([]InputChoice)
+		return choiceLabel(choices, f.Value)
+	case common.RATING:
+		value, _ := strconv.Atoi(f.Value)
+		stars := f.RatingStars()
+		if value < 1 || value > len(stars) {
+			return ``
+		}
+		if label := stars[value-1].Label; label != f.Value {
+			return label
+		}
+		return f.Value + ` / ` + strconv.Itoa(len(stars))
+	case common.STATIC, common.TEXTAREA, common.MARKDOWN:
+		return f.displayText()
+	case common.AUTOCOMPLETE:
+		if text := f.displayText(); len(text) > 0 {
+			return text
+		}
+	case common.MONEY, common.DECIMAL:
+		value := f.displayDecimal()
+		if currency := f.Currency(); len(value) > 0 && len(currency) > 0 {
+			value = currency + ` ` + value
+		}
+		return value
+	case common.DATE, common.DATETIME, common.DATETIME_LOCAL, common.TIME, common.MONTH, common.WEEK:
+		return f.displayTime()
+	}
+	return f.Value
+}
+
+// DisplayHTML returns the HTML of the value of the field in readonly mode: the sanitized value of richtext fields
+// (see SanitizeValue), the escaped DisplayValue of other fields.
+func (f *Field) DisplayHTML(fn func(string) string) template.HTML {
+	if f.Type == common.RICHTEXT {
+		return template.HTML(SanitizeValue(f.Type, f.SanitizePolicy(), f.displayText()))
+	}
+	return template.HTML(template.HTMLEscapeString(f.DisplayValue(fn)))
+}
+
+// displayText returns the text of static, textarea, editor and autocomplete fields (see SetText and SetSelectedText), or the value.
+func (f *Field) displayText() string {
+	if text, ok := f.Additional["text"].(string); ok {
+		return text
+	}
+	return f.Value
+}
+
+// displayTime formats the value of a date/time field with its display format, invalid values are returned as is.
+func (f *Field) displayTime() string {
+	format := f.DisplayFormat()
+	if len(f.Value) == 0 || len(f.Format) == 0 || format == f.Format {
+		return f.Value
+	}
+	t, err := ParseTime(f.Value, f.Format, nil)
+	if err != nil {
+		return f.Value
+	}
+	return FormatTime(t, format, nil)
+}
+
+func displayBool(b bool, fn func(string) string) string {
+	if b {
+		return fn(`Yes`)
+	}
+	return fn(`No`)
+}
+
+// checkedLabels joins the labels of the checked choices.
+func checkedLabels(choices []InputChoice) string {
+	var labels []string
+	for _, c := range choices {
+		if c.Checked {
+			labels = append(labels, c.Val)
+		}
+	}
+	return strings.Join(labels, `, `)
+}
+
+// choiceLabel returns the label of the choice with the given ID, or the ID.
+func choiceLabel(choices []InputChoice, id string) string {
+	for _, c := range choices {
+		if c.ID == id && len(c.Val) > 0 {
+			return c.Val
+		}
+	}
+	return id
+}
@@ -0,0 +1,104 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/coscms/forms/common"
+)
+
+// IsEditorType reports whether the field type is edited with a rich text (HTML) or markdown editor.
+func IsEditorType(fieldType string) bool {
+	return fieldType == common.RICHTEXT || fieldType == common.MARKDOWN
+}
+
+// RichTextField creates a rich text (HTML) editor field. The textarea is rendered with a data-editor="richtext" attribute
+// for the editor script; submitted values must be passed through SanitizeValue.
+func RichTextField(name string, rows, cols int) *Field {
+	ret := TextAreaField(name, rows, cols)
+	ret.Type = common.RICHTEXT
+	return ret
+}
+
+// MarkdownField creates a markdown editor field, see RichTextField.
+func MarkdownField(name string, rows, cols int) *Field {
+	ret := TextAreaField(name, rows, cols)
+	ret.Type = common.MARKDOWN
+	return ret
+}
+
+// EditorFieldFromInstance creates and initializes a richtext or markdown field based on its name, the reference object instance and field number.
+// This method looks for "form_rows", "form_cols", "form_policy" (name of a registered SanitizePolicy) and "form_value" tags.
+// The returned field is usable, invalid tags are ignored and returned as an error.
+func EditorFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, fieldType string) (*Field, error) {
+	ret, err := TextAreaFieldFromInstanceE(val, t, fieldNo, name, useFieldValue)
+	ret.Type = fieldType
+	errs := []error{err}
+	if policy := common.TagVal(t, fieldNo, "form_policy"); len(policy) > 0 {
+		ret.SetSanitizePolicy(policy)
+		if _, ok := GetSanitizePolicy(policy); !ok {
+			errs = append(errs, fmt.Errorf("sanitize policy not found for field %s: %s", name, policy))
+		}
+	}
+	return ret, errors.Join(errs...)
+}
+
+// SetSanitizePolicy sets the name of the SanitizePolicy applied to the submitted values of a richtext or markdown field.
+func (f *Field) SetSanitizePolicy(name string) *Field {
+	f.Additional["policy"] = name
+	return f
+}
+
+// SanitizePolicy returns the name of the SanitizePolicy of a richtext or markdown field ("" is DefaultSanitizePolicy).
+func (f *Field) SanitizePolicy() string {
+	name, _ := f.Additional["policy"].(string)
+	return name
+}
+
+// SanitizeValue sanitizes a value submitted to a field of the given type with the named policy: richtext values are
+// sanitized as HTML, markdown values as markdown; values of other field types are returned as is.
+// An unknown policy name falls back on the strictest policy (all HTML is removed), never on no sanitization.
+func SanitizeValue(fieldType string, policyName string, value string) string {
+	if !IsEditorType(fieldType) {
+		return value
+	}
+	policy, ok := GetSanitizePolicy(policyName)
+	if !ok || policy == nil {
+		policy = &SanitizePolicy{DropContent: DefaultSanitizePolicy.DropContent}
+	}
+	if fieldType == common.MARKDOWN {
+		return policy.SanitizeMarkdown(value)
+	}
+	return policy.Sanitize(value)
+}
+
+// SanitizeValues is like SanitizeValue for a list of values.
+func SanitizeValues(fieldType string, policyName string, values []string) []string {
+	if !IsEditorType(fieldType) {
+		return values
+	}
+	r := make([]string, len(values))
+	for i, v := range values {
+		r[i] = SanitizeValue(fieldType, policyName, v)
+	}
+	return r
+}
@@ -96,7 +96,8 @@ func (f *Field) SetTemplate(tmpl string, theme ...string) FieldInterface {
 		f.Theme = theme[0]
 	}
 	if len(f.Template) > 0 && f.widget != nil && f.Template != tmpl {
-		f.widget = widgets.BaseWidget(f.Theme, f.Type, tmpl)
+		f.Template = tmpl
+		return f.ReinitTemplate()
 	}
 	f.Template = tmpl
 	return f
@@ -111,11 +112,25 @@ func (f *Field) ElementType() string {
 	return f.Type
 }
 
+// ReinitTemplate 重新加载模板，模板解析失败时字段会渲染错误信息（通过 WidgetError 获取）
 func (f *Field) ReinitTemplate() FieldInterface {
-	f.widget = widgets.BaseWidget(f.Theme, f.Type, f.Template)
+	w, err := widgets.BaseWidgetE(f.Theme, f.Type, f.Template)
+	if err != nil {
+		f.widget = &widgets.ErrorWidget{Err: err}
+		return f
+	}
+	f.widget = w
 	return f
 }
 
+// WidgetError returns the error of a template that cannot be parsed.
+func (f *Field) WidgetError() error {
+	if w, ok := f.Widget().(*widgets.ErrorWidget); ok {
+		return w.Err
+	}
+	return nil
+}
+
 func (f *Field) SetName(name string) {
 	f.CurrName = name
 }
@@ -153,18 +168,16 @@ func (f *Field) Clone() config.FormElement {
 		data:         make(map[string]interface{}, len(f.data)),
 	}
 	switch chs := f.Choices.(type) {
+	case ChoiceGroups:
+		fc.Choices = chs.Clone()
 	case map[string][]InputChoice:
 		choices := make(map[string][]InputChoice, len(chs))
 		for k, v := range chs {
-			c := make([]InputChoice, len(v))
-			copy(c, v)
-			choices[k] = c
+			choices[k] = cloneChoices(v)
 		}
 		fc.Choices = choices
 	case []InputChoice:
-		c := make([]InputChoice, len(chs))
-		copy(c, chs)
-		fc.Choices = c
+		fc.Choices = cloneChoices(chs)
 	}
 	for k, v := range f.Params {
 		fc.Params[k] = v
@@ -222,6 +235,13 @@ func (f *Field) Data() map[string]interface{} {
 	}
 	safeParams := make(common.HTMLAttributes)
 	safeParams.FillFrom(f.Params)
+	choices := f.Choices
+	if f.Type == common.SELECT {
+		// the select templates expect ChoiceGroups
+		if groups, ok := ToChoiceGroups(choices); ok {
+			choices = groups
+		}
+	}
 	f.data = map[string]interface{}{
 		"classes":      f.Classes,
 		"id":           f.ID,
@@ -238,9 +258,22 @@ func (f *Field) Data() map[string]interface{} {
 		"helptext":     f.HelpText,
 		"errors":       f.Errors,
 		"container":    "form",
-		"choices":      f.Choices,
+		"choices":      choices,
 		"lang":         f.Language,
 	}
+	if IsDecimalType(f.Type) {
+		f.data["value"] = f.displayDecimal()
+		f.data["currencyAfter"] = f.NumberFormat().CurrencyAfter
+	}
+	if IsDatalistType(f.Type) || f.Type == common.TAGS {
+		if list, ok := choices.([]InputChoice); ok && len(list) > 0 {
+			f.data["datalistID"] = f.DatalistID()
+		}
+	}
+	if f.Type == common.TAGS {
+		f.data["tagValues"] = f.TagValues()
+	}
+	f.toggleData(f.data)
 	for k, v := range f.Additional {
 		f.data[k] = v
 	}
@@ -404,58 +437,40 @@ func (f *Field) SingleChoice() FieldInterface {
 	return f
 }
 
+// choice returns the choice at the given index, nil if it does not exist.
+func (f *Field) choice(i ChoiceIndex) *InputChoice {
+	switch choices := f.Choices.(type) {
+	case ChoiceGroups:
+		if group := choices.Group(i.Group); group != nil && len(group.Choices) > i.Index {
+			return &group.Choices[i.Index]
+		}
+	case []InputChoice:
+		if len(choices) > i.Index {
+			return &choices[i.Index]
+		}
+	}
+	return nil
+}
+
 // AddSelected If the field is configured as "multiple", AddSelected adds a selected value to the field (valid for SelectFields only).
 // It has no effect if type is not SELECT.
 func (f *Field) AddSelected(opt ...string) FieldInterface {
-	switch f.Type {
-	case common.SELECT:
-		for _, v := range opt {
-			i, ok := f.ChoiceKeys[v]
-			if !ok {
-				continue
-			}
-			choice := f.Choices.(map[string][]InputChoice)
-			if vc, ok := choice[i.Group]; ok {
-				if len(vc) > i.Index {
-					choice[i.Group][i.Index].Checked = true
-				}
-			}
+	for _, v := range opt {
+		i, ok := f.ChoiceKeys[v]
+		if !ok {
+			continue
 		}
-	case common.RADIO, common.CHECKBOX:
-		choice := f.Choices.([]InputChoice)
-		size := len(choice)
-		for _, v := range opt {
-			i, ok := f.ChoiceKeys[v]
-			if !ok {
-				continue
-			}
-			if size > i.Index {
-				choice[i.Index].Checked = true
-			}
+		if c := f.choice(i); c != nil {
+			c.Checked = true
 		}
 	}
 	return f
 }
 
 func (f *Field) SetSelected(opt ...string) FieldInterface {
-	switch f.Type {
-	case common.SELECT:
-		choice := f.Choices.(map[string][]InputChoice)
-		for key, i := range f.ChoiceKeys {
-			vc, ok := choice[i.Group]
-			if !ok || len(vc) <= i.Index {
-				continue
-			}
-			choice[i.Group][i.Index].Checked = slices.Contains(opt, key)
-		}
-	case common.RADIO, common.CHECKBOX:
-		choice := f.Choices.([]InputChoice)
-		size := len(choice)
-		for key, i := range f.ChoiceKeys {
-			if size <= i.Index {
-				continue
-			}
-			choice[i.Index].Checked = slices.Contains(opt, key)
+	for key, i := range f.ChoiceKeys {
+		if c := f.choice(i); c != nil {
+			c.Checked = slices.Contains(opt, key)
 		}
 	}
 	return f
@@ -464,134 +479,74 @@ func (f *Field) SetSelected(opt ...string) FieldInterface {
 // RemoveSelected If the field is configured as "multiple", AddSelected removes the selected value from the field (valid for SelectFields only).
 // It has no effect if type is not SELECT.
 func (f *Field) RemoveSelected(opt string) FieldInterface {
-	switch f.Type {
-	case common.SELECT:
-		i := f.ChoiceKeys[opt]
-		if vc, ok := f.Choices.(map[string][]InputChoice)[i.Group]; ok {
-			if len(vc) > i.Index {
-				f.Choices.(map[string][]InputChoice)[i.Group][i.Index].Checked = false
-			}
-		}
-
-	case common.RADIO, common.CHECKBOX:
-		size := len(f.Choices.([]InputChoice))
-		i := f.ChoiceKeys[opt]
-		if size > i.Index {
-			f.Choices.([]InputChoice)[i.Index].Checked = false
-		}
+	i, ok := f.ChoiceKeys[opt]
+	if !ok {
+		return f
+	}
+	if c := f.choice(i); c != nil {
+		c.Checked = false
 	}
 	return f
 }
 
+// AddChoice appends a choice to the field; select choices are added to the default ("") group.
 func (f *Field) AddChoice(key, value interface{}, checked ...bool) FieldInterface {
-	var _checked bool
-	if len(checked) > 0 && checked[0] {
-		_checked = true
+	return f.AddGroupChoice(``, key, value, checked...)
+}
+
+// isChoiceList reports whether the choices of the field type are a []InputChoice (radio, checkbox, tags and datalist suggestions).
+func isChoiceList(fieldType string) bool {
+	return fieldType == common.RADIO || fieldType == common.CHECKBOX || fieldType == common.TAGS || IsDatalistType(fieldType)
+}
+
+// AddGroupChoice appends a choice to the given group of a select field. The group is created after the existing ones if needed.
+// The group is ignored by radio and checkbox fields, and by text-like fields whose choices are rendered as a <datalist>.
+func (f *Field) AddGroupChoice(group string, key, value interface{}, checked ...bool) FieldInterface {
+	ic := InputChoice{
+		ID:      fmt.Sprint(key),
+		Val:     fmt.Sprint(value),
+		Checked: len(checked) > 0 && checked[0],
 	}
-	switch f.Type {
-	case common.SELECT:
-		if f.Choices == nil {
-			f.Choices = map[string][]InputChoice{
-				"": []InputChoice{
-					{
-						ID:      fmt.Sprint(key),
-						Val:     fmt.Sprint(value),
-						Checked: _checked,
-					},
-				},
-			}
-		} else {
-			v, _ := f.Choices.(map[string][]InputChoice)
-			v[""] = append(v[""], InputChoice{
-				ID:      fmt.Sprint(key),
-				Val:     fmt.Sprint(value),
-				Checked: _checked,
-			})
-			f.Choices = v
-		}
+	switch {
+	case f.Type == common.SELECT:
+		groups, _ := ToChoiceGroups(f.Choices)
+		g := groups.Add(group, ic)
+		f.Choices = groups
+		f.ChoiceKeys[ic.ID] = ChoiceIndex{Group: group, Index: len(g.Choices) - 1}
 
-	case common.RADIO, common.CHECKBOX:
-		if f.Choices == nil {
-			f.Choices = []InputChoice{
-				{
-					ID:      fmt.Sprint(key),
-					Val:     fmt.Sprint(value),
-					Checked: _checked,
-				},
-			}
-		} else {
-			v, _ := f.Choices.([]InputChoice)
-			v = append(v, InputChoice{
-				ID:      fmt.Sprint(key),
-				Val:     fmt.Sprint(value),
-				Checked: _checked,
-			})
-			f.Choices = v
-		}
+	case isChoiceList(f.Type):
+		v, _ := f.Choices.([]InputChoice)
+		f.Choices = append(v, ic)
+		f.ChoiceKeys[ic.ID] = ChoiceIndex{Group: ``, Index: len(v)}
 	}
 	return f
 }
 
-// SetChoices takes as input a dictionary whose key-value entries are defined as follows: key is the group name (the empty string
-// is the default group that is not explicitly rendered) and value is the list of choices belonging to that group.
-// Grouping is only useful for Select fields, while groups are ignored in Radio fields.
-// It has no effect if type is not SELECT.
+// SetChoices sets the choices of the field. Select fields take ChoiceGroups, whose groups are rendered in order (the group with
+// an empty label is the default one and is not rendered as an <optgroup>); map[string][]InputChoice is still accepted, its
+// groups are sorted by label. Radio and checkbox fields take a []InputChoice, as well as text-like fields (see IsDatalistType)
+// whose choices are rendered as a <datalist> of suggestions.
 func (f *Field) SetChoices(choices interface{}, saveIndex ...bool) FieldInterface {
 	if choices == nil {
 		return f
 	}
-	switch f.Type {
-	case common.SELECT:
-		var ch map[string][]InputChoice
-		if c, ok := choices.(map[string][]InputChoice); ok {
-			ch = c
-		} else {
-			c, y := choices.([]InputChoice)
-			if !y {
-				if v, y := choices.([]string); y { // [ID, Value, Checked]
-					c = []InputChoice{
-						InputChoice{},
-					}
-					switch len(v) {
-					case 3:
-						c[0].Checked, _ = strconv.ParseBool(v[2])
-						fallthrough
-					case 2:
-						c[0].Val = v[1]
-						fallthrough
-					case 1:
-						c[0].ID = v[0]
-					}
-				}
-			}
-			ch = map[string][]InputChoice{"": c}
-		}
+	switch {
+	case f.Type == common.SELECT:
+		ch, _ := ToChoiceGroups(choices)
 		f.Choices = ch
 		if len(saveIndex) < 1 || saveIndex[0] {
-			for k, v := range ch {
-				for idx, ipt := range v {
-					f.ChoiceKeys[ipt.ID] = ChoiceIndex{Group: k, Index: idx}
+			for _, g := range ch {
+				for idx, ipt := range g.Choices {
+					f.ChoiceKeys[ipt.ID] = ChoiceIndex{Group: g.Label, Index: idx}
 				}
 			}
 		}
 
-	case common.RADIO, common.CHECKBOX:
+	case isChoiceList(f.Type):
 		c, y := choices.([]InputChoice)
 		if !y {
 			if v, y := choices.([]string); y { // [ID, Value, Checked]
-				c = []InputChoice{
-					InputChoice{},
-				}
-				switch len(v) {
-				case 3:
-					c[0].Checked, _ = strconv.ParseBool(v[2])
-					fallthrough
-				case 2:
-					c[0].Val = v[1]
-					fallthrough
-				case 1:
-					c[0].ID = v[0]
-				}
+				c = stringsToChoices(v)
 			}
 		}
 		f.Choices = c
@@ -610,7 +565,8 @@ func (f *Field) SetText(text string) FieldInterface {
 		f.Type == common.SUBMIT ||
 		f.Type == common.RESET ||
 		f.Type == common.STATIC ||
-		f.Type == common.TEXTAREA {
+		f.Type == common.TEXTAREA ||
+		IsEditorType(f.Type) {
 		f.Additional["text"] = text
 	}
 	return f
@@ -636,6 +592,36 @@ func (f *Field) Element() *config.Element {
 	if len(f.AppendData) > 0 {
 		elem.Data = f.AppendData
 	}
+	if f.Type == common.AUTOCOMPLETE {
+		elem.URL = f.URL()
+		elem.Lookup = f.Lookup()
+	}
+	if IsEditorType(f.Type) {
+		elem.Policy = f.SanitizePolicy()
+	}
+	if IsMaskType(f.Type) {
+		elem.Mask = f.Mask()
+	}
+	if IsDecimalType(f.Type) {
+		precision := f.Precision()
+		elem.Precision = &precision
+		elem.Currency = f.Currency()
+	}
+	switch f.Type {
+	case common.SWITCH:
+		elem.Labels = f.Labels()
+	case common.RATING:
+		elem.Labels = f.Labels()
+		elem.Attributes = append(elem.Attributes, []string{`max`, strconv.Itoa(f.RatingMax())})
+	case common.TAGS:
+		maxCount, maxLength := f.TagsLimit()
+		if maxCount > 0 {
+			elem.Attributes = append(elem.Attributes, []string{`max`, strconv.Itoa(maxCount)})
+		}
+		if maxLength > 0 {
+			elem.Attributes = append(elem.Attributes, []string{`maxlength`, strconv.Itoa(maxLength)})
+		}
+	}
 	var (
 		temp string
 		join string
@@ -664,24 +650,20 @@ func (f *Field) Element() *config.Element {
 		temp = ``
 		join = ``
 	}
-	switch choices := f.Choices.(type) {
-	case map[string][]InputChoice:
-		for k, items := range choices {
-			for _, v := range items {
-				elem.Choices = append(elem.Choices, &config.Choice{
-					Group:   k,
-					Option:  []string{v.ID, v.Val},
-					Checked: v.Checked,
-				})
+	fieldChoices := f.Choices
+	if groups, ok := fieldChoices.(map[string][]InputChoice); ok {
+		fieldChoices, _ = ToChoiceGroups(groups)
+	}
+	switch choices := fieldChoices.(type) {
+	case ChoiceGroups:
+		for _, g := range choices {
+			for _, v := range g.Choices {
+				elem.Choices = append(elem.Choices, v.Config(g.Label))
 			}
 		}
 	case []InputChoice:
 		for _, v := range choices {
-			elem.Choices = append(elem.Choices, &config.Choice{
-				Group:   ``,
-				Option:  []string{v.ID, v.Val},
-				Checked: v.Checked,
-			})
+			elem.Choices = append(elem.Choices, v.Config(``))
 		}
 	}
 	return elem
@@ -709,6 +691,13 @@ func (f *Field) GetMultilingualText(recv *map[string]struct{}) {
 		}
 	}
 	switch choices := f.Choices.(type) {
+	case ChoiceGroups:
+		for _, g := range choices {
+			if len(g.Label) > 0 && !config.IsExistsKey(recv, g.Label) && !com.StrIsNumeric(g.Label) {
+				(*recv)[g.Label] = struct{}{}
+			}
+			choicesMultilingualText(g.Choices, recv)
+		}
 	case map[string][]InputChoice:
 		for group, items := range choices {
 			if len(group) > 0 && !config.IsExistsKey(recv, group) && !com.StrIsNumeric(group) {
@@ -721,10 +710,17 @@ func (f *Field) GetMultilingualText(recv *map[string]struct{}) {
 			}
 		}
 	case []InputChoice:
-		for _, v := range choices {
-			if len(v.Val) > 0 && !config.IsExistsKey(recv, v.Val) && !com.StrIsNumeric(v.Val) {
-				(*recv)[v.Val] = struct{}{}
-			}
+		choicesMultilingualText(choices, recv)
+	}
+}
+
+func choicesMultilingualText(choices []InputChoice, recv *map[string]struct{}) {
+	for _, v := range choices {
+		if len(v.Val) > 0 && !config.IsExistsKey(recv, v.Val) && !com.StrIsNumeric(v.Val) {
+			(*recv)[v.Val] = struct{}{}
+		}
+		if len(v.Description) > 0 && !config.IsExistsKey(recv, v.Description) {
+			(*recv)[v.Description] = struct{}{}
 		}
 	}
 }
@@ -1,8 +1,10 @@
 package fields
 
 import (
+	"html"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coscms/forms/common"
 	_ "github.com/coscms/forms/defaults"
@@ -50,3 +52,166 @@ func TestSelectField(t *testing.T) {
 
 	assert.Equal(t, "\n<select name=\"title\">\n        <option value=\"value1\">text1</option>\n        <option value=\"value2\" selected=\"selected\">text2</option>\n        <option value=\"value3\">text3</option>\n        <option value=\"value4\">text4</option>\n</select>", f.String())
 }
+
+func TestSelectFieldGroupOrder(t *testing.T) {
+	f := SelectField(`city`, ChoiceGroups{
+		{Label: `Zhejiang`, Choices: []InputChoice{{ID: `hz`, Val: `Hangzhou`}}},
+		{Label: `Beijing`, Choices: []InputChoice{{ID: `bj`, Val: `Beijing`}}},
+	})
+	f.SetTheme(`base`)
+	f.AddGroupChoice(`Anhui`, `hf`, `Hefei`)
+	f.SetValue(`bj`)
+
+	html := f.String()
+	assert.Less(t, strings.Index(html, `label="Zhejiang"`), strings.Index(html, `label="Beijing"`))
+	assert.Less(t, strings.Index(html, `label="Beijing"`), strings.Index(html, `label="Anhui"`))
+	assert.Contains(t, html, `<option value="bj" selected="selected">Beijing</option>`)
+
+	elem := f.Element()
+	groups := make([]string, len(elem.Choices))
+	for i, c := range elem.Choices {
+		groups[i] = c.Group
+	}
+	assert.Equal(t, []string{`Zhejiang`, `Beijing`, `Anhui`}, groups)
+
+	// the map form is still accepted, groups are sorted by label
+	f = SelectField(`city`, map[string][]InputChoice{
+		`b`: {{ID: `2`, Val: `Two`}},
+		``:  {{ID: `0`, Val: `Zero`}},
+		`a`: {{ID: `1`, Val: `One`}},
+	})
+	groups = groups[:0]
+	for _, g := range f.Choices.(ChoiceGroups) {
+		groups = append(groups, g.Label)
+	}
+	assert.Equal(t, []string{``, `a`, `b`}, groups)
+}
+
+func TestRichChoices(t *testing.T) {
+	f := RadioField(`plan`, []InputChoice{
+		{ID: `free`, Val: `Free`, Description: `For personal use`, Icon: `fa fa-user`},
+		{ID: `pro`, Val: `Pro`, Disabled: true, Data: map[string]string{`price`: `9`, `bad"key`: `x`}},
+	})
+	f.SetTheme(`base`)
+	html := f.String()
+	assert.Contains(t, html, `<i class="fa fa-user"></i> Free</label>`)
+	assert.Contains(t, html, `<small>For personal use</small>`)
+	assert.Contains(t, html, `value="pro" disabled="disabled" data-price="9">`)
+	assert.NotContains(t, html, `bad`)
+
+	s := SelectField(`plan`, f.Choices.([]InputChoice))
+	s.SetTheme(`base`)
+	assert.Contains(t, s.String(), `<option value="free" title="For personal use" data-icon="fa fa-user">Free</option>`)
+
+	elem := s.Element()
+	assert.True(t, elem.Choices[1].Disabled)
+	assert.Equal(t, `9`, elem.Choices[1].Data[`price`])
+	cloned := elem.Clone()
+	cloned.Choices[1].Data[`price`] = `10`
+	assert.Equal(t, `9`, elem.Choices[1].Data[`price`])
+
+	ic := ChoiceFromConfig(elem.Choices[0], strings.ToUpper)
+	assert.Equal(t, InputChoice{ID: `free`, Val: `FREE`, Description: `FOR PERSONAL USE`, Icon: `fa fa-user`}, ic)
+}
+
+func TestDecimal(t *testing.T) {
+	nf := NumberFormat{Decimal: `,`, Thousands: ` `}
+	assert.Equal(t, `-1 234 567,05`, FormatDecimal(-123456705, 2, nf))
+	assert.Equal(t, `0,05`, FormatDecimal(5, 2, nf))
+	assert.Equal(t, `123`, FormatDecimal(123, 0, nf))
+	n, err := ParseDecimal(`1 234,5`, 2, nf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123450), n)
+	_, err = ParseDecimal(`1,234`, 2, nf)
+	assert.Error(t, err)
+	_, err = ParseDecimal(`1e6`, 2, CanonicalNumberFormat)
+	assert.Error(t, err)
+	n, err = DecimalToMinor(0.1+0.2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, `0.30`, DecimalString(n, 2))
+}
+
+func TestSanitize(t *testing.T) {
+	p := DefaultSanitizePolicy
+	assert.Equal(t, `<p>Hi <b>there</b></p>`, p.Sanitize(`<p onclick="x()">Hi <b>there</b><script>alert(1)</script></p>`))
+	assert.Equal(t, `<a>x</a><img src="a.png">`, p.Sanitize(`<a href="java&#x09;script:alert(1)">x</a><img src=a.png onerror=alert(1)>`))
+	assert.Equal(t, `<a href="https://example.com/?a=1&amp;b=2">x</a>`, p.Sanitize(`<A HREF='https://example.com/?a=1&amp;b=2'>x`))
+	assert.Equal(t, `1 &lt; 2 &amp; text`, p.Sanitize(`1 < 2 & <!-- c --><unknown>text</unknown><style>p{}</style>`))
+
+	md := "# Title <script>x</script>\n\n[a](javascript:alert(1)) <i onmouseover=x>b</i> `<script>`\n```\n<script>code</script>\n```\n"
+	assert.Equal(t, "# Title \n\n[a]() <i>b</i> `<script>`\n```\n<script>code</script>\n```\n", p.SanitizeMarkdown(md))
+	for _, link := range []string{
+		`[x](javascript&#58;alert(1))`, `[x](javascript&colon;alert(1))`, `[x](<JavaScript&#x3A;alert(1)>)`,
+		`[x](javascript\:alert(1))`, `[x](java&Tab;script:alert(1))`, `<a href="javascript&#58;alert(1)">x</a>`,
+	} {
+		assert.NotContains(t, strings.ToLower(html.UnescapeString(p.SanitizeMarkdown(link))), `script:`, link)
+	}
+	assert.Equal(t, "[x]: \n\n[x]", p.SanitizeMarkdown("[x]: javascript&#x3A;alert(1)\n\n[x]"))
+	assert.Equal(t, `[x](https://example.com/a_(b) "t") [y](/path)`, p.SanitizeMarkdown(`[x](https://example.com/a_(b) "t") [y](/path)`))
+	assert.Equal(t, `<b>x</b>`, p.Sanitize(`<object><object></object><script>x</script></object><b>x`))
+}
+
+func TestUnmask(t *testing.T) {
+	mask := `(999) 999-9999`
+	assert.Equal(t, `5551234567`, Unmask(mask, `(555) 123-4567`))
+	assert.Equal(t, `5551234567`, Unmask(mask, `5551234567`))
+	assert.Equal(t, `5551234567`, Unmask(mask, `555-123-4567`))
+	assert.Equal(t, `5551234`, Unmask(`+1 999-9999`, `+1 555-1234`))
+	assert.Equal(t, `1234567`, Unmask(`+1 999-9999`, `1234567`))
+	assert.Equal(t, `1234567`, Unmask(`+1 999-9999`, `123-4567`))
+	assert.Equal(t, `12`, Unmask(`+1 999-9999`, `+1 12`))
+	assert.Equal(t, `AB12`, Unmask(`aa-99`, `AB-12`))
+}
+
+func TestFilterValues(t *testing.T) {
+	vals, err := FilterValues([]string{`trim`, `collapse`, `lower`}, []string{"  John \t DOE ", `A`})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`john doe`, `a`}, vals)
+	vals, _ = FilterValues([]string{`nfc`, `stripctl`}, []string{"Café\x00\n"})
+	assert.Equal(t, []string{"Café\n"}, vals)
+	vals, _ = FilterValues([]string{`slugify`}, []string{`Hello World`})
+	assert.Equal(t, []string{`hello-world`}, vals)
+	_, err = FilterValues([]string{`trim`, `unknown`}, []string{`a`})
+	assert.ErrorIs(t, err, ErrUnknownFilter)
+
+	RegisterFilter(`digits`, func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, s)
+	})
+	vals, _ = FilterValues([]string{`digits`}, []string{`555-1234`})
+	assert.Equal(t, []string{`5551234`}, vals)
+}
+
+func TestChoiceMap(t *testing.T) {
+	f := SelectField(`s`, ChoiceGroups{
+		{Label: `b`, Choices: []InputChoice{{ID: `1`, Val: `One`}}},
+		{Label: ``, Choices: []InputChoice{{ID: `2`, Val: `Two`}}},
+	})
+	assert.Equal(t, map[string][]InputChoice{
+		`b`: {{ID: `1`, Val: `One`}},
+		``:  {{ID: `2`, Val: `Two`}},
+	}, f.ChoiceMap())
+}
+
+func TestDatalistID(t *testing.T) {
+	f := FieldWithType(`color`, common.TEXT)
+	assert.Equal(t, `datalist-color`, f.DatalistID())
+	f.SetName(`Language[en][color]`)
+	assert.Equal(t, `datalist-language-en-color`, f.DatalistID())
+	f.SetID(`color`)
+	assert.Equal(t, `color-datalist`, f.DatalistID())
+}
+
+func TestFormatTimeZone(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone(`PST`, -8*3600)
+	defer func() { time.Local = local }()
+
+	tm := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, `2024-03-01`, FormatTime(tm, DATE_FORMAT, nil))
+	assert.Equal(t, `2024-02-29T16:00`, FormatTime(tm, DATETIME_LOCAL_FORMAT, time.Local))
+}
@@ -0,0 +1,125 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/coscms/forms/common"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrUnknownFilter is returned for filter names that are not registered.
+var ErrUnknownFilter = errors.New(`unknown filter`)
+
+// ValueFilter normalizes a submitted value. Filters run in the declared order on the raw submitted values,
+// before input masks are removed, HTML is sanitized, the values are validated and bound.
+type ValueFilter func(string) string
+
+var (
+	valueFilters = map[string]ValueFilter{
+		`trim`:     strings.TrimSpace,
+		`collapse`: CollapseSpaces,
+		`lower`:    strings.ToLower,
+		`upper`:    strings.ToUpper,
+		`email`:    func(s string) string { return strings.ToLower(strings.TrimSpace(s)) },
+		`nfc`:      norm.NFC.String,
+		`stripctl`: StripControl,
+		`slugify`:  common.Slugify,
+	}
+	valueFiltersMu sync.RWMutex
+)
+
+// RegisterFilter adds or replaces a named filter, used in the "form_filter" tag or the "filters" property of an element.
+func RegisterFilter(name string, filter ValueFilter) {
+	valueFiltersMu.Lock()
+	valueFilters[name] = filter
+	valueFiltersMu.Unlock()
+}
+
+// GetFilter returns the filter registered with the given name.
+func GetFilter(name string) (ValueFilter, bool) {
+	valueFiltersMu.RLock()
+	filter, ok := valueFilters[name]
+	valueFiltersMu.RUnlock()
+	return filter, ok
+}
+
+// ParseFilters splits the value of the "form_filter" tag, ex: "trim;collapse;lower".
+func ParseFilters(tag string) []string {
+	var names []string
+	for _, name := range strings.Split(tag, `;`) {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CheckFilters returns an error wrapping ErrUnknownFilter if one of the filters is not registered.
+func CheckFilters(names []string) error {
+	for _, name := range names {
+		if _, ok := GetFilter(name); !ok {
+			return fmt.Errorf(`%w: %s`, ErrUnknownFilter, name)
+		}
+	}
+	return nil
+}
+
+// FilterValues applies the filters to every value, in order. The values are returned as is if there is no filter.
+func FilterValues(names []string, vals []string) ([]string, error) {
+	if len(names) == 0 {
+		return vals, nil
+	}
+	filters := make([]ValueFilter, len(names))
+	for i, name := range names {
+		filter, ok := GetFilter(name)
+		if !ok {
+			return vals, fmt.Errorf(`%w: %s`, ErrUnknownFilter, name)
+		}
+		filters[i] = filter
+	}
+	r := make([]string, len(vals))
+	for i, v := range vals {
+		for _, filter := range filters {
+			v = filter(v)
+		}
+		r[i] = v
+	}
+	return r, nil
+}
+
+// CollapseSpaces trims the value and replaces every sequence of white space with a single space.
+func CollapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), ` `)
+}
+
+// StripControl removes the control characters, except tabs and line breaks.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,151 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// StructWidgets are the widgets that can be set with the "form_widget" tag of struct fields.
+var StructWidgets = map[string]struct{}{
+	"color": {}, "email": {}, "file": {}, "image": {}, "month": {}, "search": {}, "tel": {}, "url": {}, "week": {},
+	"text": {}, "hidden": {}, "textarea": {}, "password": {}, "select": {}, "date": {}, "datetime": {}, "datetime-local": {}, "time": {},
+	"number": {}, "range": {}, "radio": {}, "checkbox": {}, "static": {}, "autocomplete": {}, "money": {}, "decimal": {}, "richtext": {}, "markdown": {},
+	"switch": {}, "rating": {}, "tags": {}, "address": {},
+}
+
+// IndirectType returns the type a struct field is rendered as: pointers are dereferenced
+// and database/sql Null types (sql.NullString, sql.Null[T], ...) are unwrapped to their value type.
+func IndirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if elem, ok := SQLNullType(t); ok {
+		return elem
+	}
+	return t
+}
+
+// SQLNullType reports whether t is one of the database/sql Null types and returns the type of its value.
+func SQLNullType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != `database/sql` || !strings.HasPrefix(t.Name(), `Null`) {
+		return nil, false
+	}
+	if t.NumField() != 2 || t.Field(1).Name != `Valid` {
+		return nil, false
+	}
+	return t.Field(0).Type, true
+}
+
+// IsMultiValue reports whether the field type holds several values (slices and arrays, except []byte).
+func IsMultiValue(t reflect.Type) bool {
+	t = IndirectType(t)
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
+
+// instanceValue 返回结构体字段的值：指针会被解引用，实现了 driver.Valuer 的类型（如 sql.NullString）返回 Value() 的结果，空值返回 nil
+func instanceValue(val reflect.Value, fieldNo int) interface{} {
+	field := val.Field(fieldNo)
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	if !field.CanInterface() {
+		return nil
+	}
+	v := field.Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		if b, ok := dv.([]byte); ok {
+			return string(b)
+		}
+		return dv
+	}
+	return v
+}
+
+// formatValue 将值转换为字符串，切片的元素以“,”连接
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ``
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, rv.Len())
+		for i := range parts {
+			parts[i] = formatValue(rv.Index(i).Interface())
+		}
+		return strings.Join(parts, `,`)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func instanceString(val reflect.Value, fieldNo int) string {
+	return formatValue(instanceValue(val, fieldNo))
+}
+
+// InstanceValues returns the values of a struct field as strings (every element for slices), used to select choices.
+// The "form_value" tag (comma separated) is used when useFieldValue is false.
+func InstanceValues(val reflect.Value, t reflect.Type, fieldNo int, useFieldValue bool) []string {
+	if !useFieldValue {
+		if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
+			return strings.Split(v, ",")
+		}
+		return nil
+	}
+	v := instanceValue(val, fieldNo)
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if _, ok := v.([]byte); ok {
+			break
+		}
+		list := make([]string, rv.Len())
+		for i := range list {
+			list[i] = formatValue(rv.Index(i).Interface())
+		}
+		return list
+	}
+	return []string{formatValue(v)}
+}
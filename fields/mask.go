@@ -0,0 +1,144 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// Input masks describe the format of a value, ex: "(999) 999-9999". "9" is a digit, "a" is a letter,
+// "*" is a letter or a digit; any other character is a literal inserted while typing and removed by Unmask.
+
+// IsMaskType reports whether an input mask can be set on fields of the given type.
+func IsMaskType(fieldType string) bool {
+	switch fieldType {
+	case common.TEXT, common.TEL, common.SEARCH:
+		return true
+	}
+	return false
+}
+
+// isMaskSlot reports whether the mask character is a placeholder for a typed character.
+func isMaskSlot(r rune) bool {
+	return r == '9' || r == 'a' || r == '*'
+}
+
+// maskAccepts reports whether the character can be typed in the slot.
+func maskAccepts(slot rune, r rune) bool {
+	isDigit := r >= '0' && r <= '9'
+	isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	switch slot {
+	case '9':
+		return isDigit
+	case 'a':
+		return isLetter
+	}
+	return isDigit || isLetter
+}
+
+// MaskPattern returns the regular expression of the values formatted with the mask, used as the pattern attribute.
+func MaskPattern(mask string) string {
+	var b strings.Builder
+	for _, r := range mask {
+		switch r {
+		case '9':
+			b.WriteString(`[0-9]`)
+		case 'a':
+			b.WriteString(`[A-Za-z]`)
+		case '*':
+			b.WriteString(`[A-Za-z0-9]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// Unmask removes the literal characters of the mask from a value, ex: "(555) 123-4567" becomes "5551234567".
+// Values typed without the literals are returned as is: letters and digits of the mask (ex: "1" in "+1 999-9999")
+// are only taken for literals while the value follows the mask exactly, so "1234567" stays "1234567".
+func Unmask(mask string, value string) string {
+	if len(mask) == 0 {
+		return value
+	}
+	m := []rune(mask)
+	literals := strings.Map(func(r rune) rune {
+		if isMaskSlot(r) {
+			return -1
+		}
+		return r
+	}, mask)
+	var b strings.Builder
+	i := 0
+	exact := true // every literal so far was typed
+	for _, r := range value {
+		// literals that were not typed are skipped
+		for i < len(m) && !isMaskSlot(m[i]) && (m[i] != r || !exact && maskAccepts('*', m[i])) {
+			i++
+			exact = false
+		}
+		if i < len(m) && !isMaskSlot(m[i]) {
+			i++
+			continue
+		}
+		if i < len(m) && !maskAccepts(m[i], r) && strings.ContainsRune(literals, r) {
+			// literal typed at another position, ex: "555-123-4567" for "(999) 999-9999"
+			continue
+		}
+		b.WriteRune(r)
+		if i < len(m) {
+			i++
+		}
+	}
+	return b.String()
+}
+
+// UnmaskValues is like Unmask for a list of values.
+func UnmaskValues(mask string, values []string) []string {
+	if len(mask) == 0 {
+		return values
+	}
+	r := make([]string, len(values))
+	for i, v := range values {
+		r[i] = Unmask(mask, v)
+	}
+	return r
+}
+
+// SetMask sets the input mask of a text, tel or search field. The mask is rendered as a data-mask attribute
+// formatted while typing by the form script of the default themes, and the pattern attribute is replaced by
+// the pattern of the formatted values (validation rules apply to unmasked values on the server side).
+func (f *Field) SetMask(mask string) *Field {
+	if len(mask) == 0 {
+		delete(f.Additional, "mask")
+		return f
+	}
+	f.Additional["mask"] = mask
+	f.SetParam("pattern", MaskPattern(mask))
+	return f
+}
+
+// Mask returns the input mask of the field.
+func (f *Field) Mask() string {
+	mask, _ := f.Additional["mask"].(string)
+	return mask
+}
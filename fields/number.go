@@ -21,6 +21,7 @@ package fields
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/coscms/forms/common"
 )
@@ -48,6 +49,8 @@ func NumberFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, nam
 	// check tags
 	if v := common.TagVal(t, fieldNo, "form_min"); v != "" {
 		ret.SetParam("min", v)
+	} else if isUnsigned(t.Field(fieldNo).Type) {
+		ret.SetParam("min", "0")
 	}
 	if v := common.TagVal(t, fieldNo, "form_max"); v != "" {
 		ret.SetParam("max", v)
@@ -61,8 +64,23 @@ func NumberFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, nam
 
 // RangeFieldFromInstance creates and initializes a range field based on its name, the reference object instance and field number.
 // This method looks for "form_min", "form_max", "form_step" and "form_value" tags to add additional parameters to the field.
-func RangeFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
+// "form_choices" tag is a list of <value>|<label> tick marks, ex: "0|Low|50|Medium|100|High".
+func RangeFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, args ...func(string) string) *Field {
 	ret := RangeField(name, 0, 10, 1)
+	if tag := common.TagVal(t, fieldNo, "form_choices"); len(tag) > 0 {
+		if _, ok := ChoiceProviderName(tag); !ok {
+			fn := common.LabelFn
+			if len(args) > 0 {
+				fn = args[0]
+			}
+			ticks := strings.Split(tag, "|")
+			choices := make([]InputChoice, 0, len(ticks)/2)
+			for i, j := 0, len(ticks)-1; i < j; i += 2 {
+				choices = append(choices, InputChoice{ID: ticks[i], Val: fn(ticks[i+1])})
+			}
+			ret.SetChoices(choices)
+		}
+	}
 	// check tags
 	if v := common.TagVal(t, fieldNo, "form_min"); v != "" {
 		ret.SetParam("min", v)
@@ -76,3 +94,11 @@ func RangeFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name
 	ret.SetValue(defaultValue(val, t, fieldNo, useFieldValue))
 	return ret
 }
+
+func isUnsigned(t reflect.Type) bool {
+	switch IndirectType(t).Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
@@ -19,17 +19,109 @@
 package fields
 
 import (
-	"fmt"
+	"html/template"
 	"reflect"
 	"strings"
 
 	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
 )
 
 // InputChoice ID - Value pair used to define an option for select and redio input fields.
 type InputChoice struct {
-	ID, Val string
-	Checked bool
+	ID, Val     string
+	Checked     bool
+	Disabled    bool              `json:",omitempty" xml:",omitempty"`
+	Description string            `json:",omitempty" xml:",omitempty"` // help text of the option (the title attribute of select options)
+	Icon        string            `json:",omitempty" xml:",omitempty"` // icon CSS class, ex: "fa fa-star"
+	Image       string            `json:",omitempty" xml:",omitempty"` // image URL, used by card-style radios and checkboxes
+	Data        map[string]string `json:",omitempty" xml:"-"`          // rendered as data-* attributes
+}
+
+// DataAttrs returns the Data entries as data-* attributes. Keys containing characters not allowed in attribute names are skipped.
+func (c InputChoice) DataAttrs() common.HTMLAttributes {
+	attrs := make(common.HTMLAttributes, len(c.Data))
+	for k, v := range c.Data {
+		if !isAttrName(k) {
+			continue
+		}
+		attrs[template.HTMLAttr(`data-`+k)] = v
+	}
+	return attrs
+}
+
+// OptionAttrs returns the data-* attributes of a select option: Data, plus Icon and Image as data-icon and data-image
+// (options can not contain markup, so they are left to JS widgets).
+func (c InputChoice) OptionAttrs() common.HTMLAttributes {
+	attrs := c.DataAttrs()
+	if len(c.Icon) > 0 {
+		attrs[`data-icon`] = c.Icon
+	}
+	if len(c.Image) > 0 {
+		attrs[`data-image`] = c.Image
+	}
+	return attrs
+}
+
+// Config converts the choice to a config choice of the given group.
+func (c InputChoice) Config(group string) *config.Choice {
+	choice := &config.Choice{
+		Group:       group,
+		Option:      []string{c.ID, c.Val},
+		Checked:     c.Checked,
+		Disabled:    c.Disabled,
+		Description: c.Description,
+		Icon:        c.Icon,
+		Image:       c.Image,
+	}
+	if len(c.Data) > 0 {
+		choice.Data = make(map[string]string, len(c.Data))
+		for k, v := range c.Data {
+			choice.Data[k] = v
+		}
+	}
+	return choice
+}
+
+// ChoiceFromConfig converts a config choice (Option is a ["value","text"] pair) to an InputChoice.
+// The text and the description are translated by fn.
+func ChoiceFromConfig(c *config.Choice, fn func(string) string) InputChoice {
+	ic := InputChoice{
+		Checked:  c.Checked,
+		Disabled: c.Disabled,
+		Icon:     c.Icon,
+		Image:    c.Image,
+	}
+	if len(c.Option) > 0 {
+		ic.ID = c.Option[0]
+	}
+	if len(c.Option) > 1 {
+		ic.Val = fn(c.Option[1])
+	}
+	if len(c.Description) > 0 {
+		ic.Description = fn(c.Description)
+	}
+	if len(c.Data) > 0 {
+		ic.Data = make(map[string]string, len(c.Data))
+		for k, v := range c.Data {
+			ic.Data[k] = v
+		}
+	}
+	return ic
+}
+
+func isAttrName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 type ChoiceIndex struct {
@@ -38,10 +130,9 @@ type ChoiceIndex struct {
 }
 
 func defaultValue(val reflect.Value, t reflect.Type, fieldNo int, useFieldValue bool) string {
-	field := val.Field(fieldNo)
 	var v string
 	if useFieldValue {
-		v = fmt.Sprintf("%v", field.Interface())
+		v = instanceString(val, fieldNo)
 	} else {
 		v = common.TagVal(t, fieldNo, "form_value")
 	}
@@ -73,7 +164,7 @@ func RadioFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name
 	chMap := make(map[string]string)
 	for i, j := 0, len(choices)-1; i < j; i += 2 {
 		ret.ChoiceKeys[choices[i]] = ChoiceIndex{Group: "", Index: len(chArr)}
-		chArr = append(chArr, InputChoice{choices[i], fn(choices[i+1]), false})
+		chArr = append(chArr, InputChoice{ID: choices[i], Val: fn(choices[i+1])})
 		chMap[choices[i]] = choices[i+1]
 	}
 	ret.SetChoices(chArr, false)
@@ -86,11 +177,12 @@ func RadioFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name
 
 // ================ SELECT
 
-// SelectField creates a default select input field with the provided name and map of choices. Choices for SelectField are grouped
-// by name (if <optgroup> is needed); "" group is the default one and does not trigger a <optgroup></optgroup> rendering.
-func SelectField(name string, choices map[string][]InputChoice) *Field {
+// SelectField creates a default select input field with the provided name and choices (ChoiceGroups, or map[string][]InputChoice
+// whose groups are sorted by label). Groups are rendered in order as <optgroup>; "" group is the default one and does not
+// trigger a <optgroup></optgroup> rendering.
+func SelectField(name string, choices interface{}) *Field {
 	ret := FieldWithType(name, common.SELECT)
-	ret.Choices = map[string][]InputChoice{}
+	ret.Choices = ChoiceGroups{}
 	ret.SetChoices(choices)
 	return ret
 }
@@ -105,23 +197,25 @@ func SelectFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, nam
 		fn = args[0]
 	}
 	choices := strings.Split(common.TagVal(t, fieldNo, "form_choices"), "|")
-	chArr := make(map[string][]InputChoice)
+	chArr := ChoiceGroups{}
 	ret := SelectField(name, chArr)
 	chMap := make(map[string]string)
 	for i, j := 0, len(choices)-2; i < j; i += 3 {
 		optgroupLabel := fn(choices[i])
-		if _, ok := chArr[optgroupLabel]; !ok {
-			chArr[optgroupLabel] = make([]InputChoice, 0)
-		}
 		id := choices[i+1]
-		ret.ChoiceKeys[id] = ChoiceIndex{Group: optgroupLabel, Index: len(chArr[optgroupLabel])}
-		chArr[optgroupLabel] = append(chArr[optgroupLabel], InputChoice{id, fn(choices[i+2]), false})
+		group := chArr.Add(optgroupLabel, InputChoice{ID: id, Val: fn(choices[i+2])})
+		ret.ChoiceKeys[id] = ChoiceIndex{Group: optgroupLabel, Index: len(group.Choices) - 1}
 		chMap[id] = choices[i+2]
 	}
 	ret.SetChoices(chArr, false)
 	if _, ok := options["multiple"]; ok {
 		ret.MultipleChoice()
 	}
+	if IsMultiValue(t.Field(fieldNo).Type) {
+		ret.MultipleChoice()
+		ret.SetSelected(InstanceValues(val, t, fieldNo, useFieldValue)...)
+		return ret
+	}
 	v := defaultValue(val, t, fieldNo, useFieldValue)
 	if _, ok := options["forceSetValue"]; ok {
 		ret.SetValue(v)
@@ -154,13 +248,18 @@ func CheckboxFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, n
 	chMap := make(map[string]string)
 	for i, j := 0, len(choices)-1; i < j; i += 2 {
 		ret.ChoiceKeys[choices[i]] = ChoiceIndex{Group: "", Index: len(chArr)}
-		chArr = append(chArr, InputChoice{choices[i], fn(choices[i+1]), false})
+		chArr = append(chArr, InputChoice{ID: choices[i], Val: fn(choices[i+1])})
 		chMap[choices[i]] = choices[i+1]
 	}
-	ret.SetChoices(choices, false)
+	ret.SetChoices(chArr, false)
 	if len(ret.Choices.([]InputChoice)) > 1 {
 		ret.MultipleChoice()
 	}
+	if IsMultiValue(t.Field(fieldNo).Type) {
+		ret.MultipleChoice()
+		ret.SetSelected(InstanceValues(val, t, fieldNo, useFieldValue)...)
+		return ret
+	}
 	v := defaultValue(val, t, fieldNo, useFieldValue)
 	if _, ok := chMap[v]; ok {
 		ret.SetValue(v)
@@ -181,19 +280,16 @@ func Checkbox(name string, checked bool) *Field {
 // CheckboxFromInstance creates and initializes a checkbox field based on its name, the reference object instance, field number and field options.
 func CheckboxFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, options map[string]struct{}) *Field {
 	ret := FieldWithType(name, common.CHECKBOX)
-	ret.SetValue("true")
+	ret.Value = "true"
 	checked := false
 	if _, ok := options["checked"]; ok {
 		checked = true
 	} else {
 		if useFieldValue {
-			checked = val.Field(fieldNo).Bool()
+			checked, _ = instanceValue(val, fieldNo).(bool)
 		}
 	}
-	if checked {
-		ret.AddTag("checked")
-	}
 	ret.Choices = []InputChoice{}
-	ret.SetChoices(InputChoice{`true`, ``, checked})
+	ret.SetChoices([]InputChoice{{ID: `true`, Checked: checked}})
 	return ret
 }
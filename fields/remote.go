@@ -0,0 +1,36 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+// SetRemote sets the URL of the remote validation endpoint of the field, rendered as the data-remote attribute.
+// Client-side code posts the values of the form and the name of the field (see forms.RemoteFieldParam) to it on blur.
+func (f *Field) SetRemote(url string) *Field {
+	if len(url) == 0 {
+		delete(f.Additional, "remote")
+		return f
+	}
+	f.Additional["remote"] = url
+	return f
+}
+
+// Remote returns the URL of the remote validation endpoint of the field.
+func (f *Field) Remote() string {
+	url, _ := f.Additional["remote"].(string)
+	return url
+}
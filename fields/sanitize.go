@@ -0,0 +1,386 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+
+	htmlx "golang.org/x/net/html"
+)
+
+// SanitizePolicy is an allow-list of the HTML kept by Sanitize: elements and attributes that are not listed are removed
+// (the text content of removed elements is kept, except for the elements listed in DropContent) and comments are dropped.
+type SanitizePolicy struct {
+	Elements    map[string][]string // allowed elements (lower case) and their allowed attributes
+	GlobalAttrs []string            // attributes allowed on every allowed element
+	URLSchemes  []string            // allowed schemes of href, src and cite attributes, relative URLs are always allowed
+	DropContent []string            // elements removed together with their content
+}
+
+// DefaultSanitizePolicy is the policy used by richtext and markdown fields without a named policy.
+// It keeps basic formatting, lists, tables, links and images; scripts, styles, event handlers and frames are removed.
+var DefaultSanitizePolicy = &SanitizePolicy{
+	Elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+		"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"b": nil, "i": nil, "u": nil, "s": nil, "em": nil, "strong": nil, "small": nil, "mark": nil,
+		"del": nil, "ins": nil, "sub": nil, "sup": nil, "code": nil, "pre": nil, "kbd": nil,
+		"blockquote": {"cite"}, "q": {"cite"},
+		"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+		"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+		"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+		"a":      {"href", "title"},
+		"img":    {"src", "alt", "title", "width", "height"},
+		"figure": nil, "figcaption": nil,
+	},
+	GlobalAttrs: []string{"class"},
+	URLSchemes:  []string{"http", "https", "mailto"},
+	DropContent: []string{"script", "style", "iframe", "object", "embed", "noscript", "template", "textarea", "select", "title"},
+}
+
+var (
+	sanitizePolicies   = map[string]*SanitizePolicy{}
+	sanitizePoliciesMu sync.RWMutex
+)
+
+// RegisterSanitizePolicy registers a named sanitize policy, used with the "form_policy" tag or the "policy" property of an element.
+func RegisterSanitizePolicy(name string, policy *SanitizePolicy) {
+	sanitizePoliciesMu.Lock()
+	sanitizePolicies[name] = policy
+	sanitizePoliciesMu.Unlock()
+}
+
+// UnregisterSanitizePolicy removes a named sanitize policy.
+func UnregisterSanitizePolicy(name string) {
+	sanitizePoliciesMu.Lock()
+	delete(sanitizePolicies, name)
+	sanitizePoliciesMu.Unlock()
+}
+
+// GetSanitizePolicy returns the sanitize policy registered with the given name, DefaultSanitizePolicy for "".
+func GetSanitizePolicy(name string) (*SanitizePolicy, bool) {
+	if len(name) == 0 {
+		return DefaultSanitizePolicy, true
+	}
+	sanitizePoliciesMu.RLock()
+	policy, ok := sanitizePolicies[name]
+	sanitizePoliciesMu.RUnlock()
+	return policy, ok
+}
+
+// voidElements are the elements without closing tag.
+var voidElements = map[string]struct{}{
+	"br": {}, "hr": {}, "img": {}, "wbr": {}, "col": {}, "area": {}, "source": {}, "track": {},
+}
+
+// Sanitize removes from s the HTML that is not allowed by the policy. Unclosed elements are closed and text is re-escaped,
+// so the result is safe to render as is.
+func (p *SanitizePolicy) Sanitize(s string) string {
+	return p.sanitize(s, func(b *strings.Builder, text string) {
+		b.WriteString(html.EscapeString(html.UnescapeString(text)))
+	})
+}
+
+var (
+	markdownFence = regexp.MustCompile("^ {0,3}(```|~~~)")
+	markdownLink  = regexp.MustCompile(`(?m)\]\([ \t]*\n?[ \t]*|^ {0,3}\[[^\]]+\]:[ \t]*\n?[ \t]*`)
+)
+
+// SanitizeMarkdown removes the HTML that is not allowed by the policy from markdown source and the link destinations
+// with schemes that are not allowed. Code blocks and code spans are kept as is, the markdown renderer escapes them.
+func (p *SanitizePolicy) SanitizeMarkdown(s string) string {
+	var (
+		b     strings.Builder
+		text  strings.Builder
+		fence string
+	)
+	flush := func() {
+		b.WriteString(p.sanitizeMarkdownText(text.String()))
+		text.Reset()
+	}
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			if len(fence) == 0 {
+				flush()
+				fence = m[1]
+				b.WriteString(line)
+				continue
+			}
+			if m[1] == fence {
+				fence = ``
+				b.WriteString(line)
+				continue
+			}
+		}
+		if len(fence) > 0 {
+			b.WriteString(line)
+		} else {
+			text.WriteString(line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// sanitizeMarkdownText sanitizes markdown text outside of code blocks, code spans are kept as is.
+func (p *SanitizePolicy) sanitizeMarkdownText(s string) string {
+	var b strings.Builder
+	for len(s) > 0 {
+		start := strings.Index(s, "`")
+		if start < 0 {
+			break
+		}
+		n := len(s[start:]) - len(strings.TrimLeft(s[start:], "`"))
+		ticks := s[start : start+n]
+		end := strings.Index(s[start+n:], ticks)
+		if end < 0 {
+			break
+		}
+		end += start + n + n
+		b.WriteString(p.sanitizeMarkdownHTML(s[:start]))
+		b.WriteString(s[start:end])
+		s = s[end:]
+	}
+	b.WriteString(p.sanitizeMarkdownHTML(s))
+	return b.String()
+}
+
+func (p *SanitizePolicy) sanitizeMarkdownHTML(s string) string {
+	s = p.sanitize(s, func(b *strings.Builder, text string) {
+		b.WriteString(strings.ReplaceAll(text, `<`, `&lt;`))
+	})
+	return p.sanitizeMarkdownLinks(s)
+}
+
+// sanitizeMarkdownLinks removes the link destinations (inline links and link reference definitions) with schemes
+// that are not allowed. Destinations are checked as the markdown renderer reads them: backslash escapes and
+// entities are decoded first, so "javascript&#58;" and "javascript&colon;" are caught too.
+func (p *SanitizePolicy) sanitizeMarkdownLinks(s string) string {
+	var b strings.Builder
+	for {
+		loc := markdownLink.FindStringIndex(s)
+		if loc == nil {
+			break
+		}
+		b.WriteString(s[:loc[1]])
+		s = s[loc[1]:]
+		end := markdownDestinationEnd(s)
+		if dest := s[:end]; !p.allowedURL(unescapeMarkdown(dest)) {
+			s = s[end:]
+			continue
+		}
+		b.WriteString(s[:end])
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// markdownDestinationEnd returns the length of the link destination at the start of s:
+// "<...>" or a run of characters without spaces and with balanced parentheses.
+func markdownDestinationEnd(s string) int {
+	if strings.HasPrefix(s, `<`) {
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '>':
+				return i + 1
+			case '\n', '<':
+				return i
+			}
+		}
+		return len(s)
+	}
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case c <= ' ':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// unescapeMarkdown decodes the backslash escapes and the entities of a link destination.
+func unescapeMarkdown(dest string) string {
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, `<`), `>`)
+	var b strings.Builder
+	for i := 0; i < len(dest); i++ {
+		if dest[i] == '\\' && i+1 < len(dest) && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", dest[i+1]) > -1 {
+			i++
+		}
+		b.WriteByte(dest[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+// sanitize tokenizes s with the HTML5 tokenizer and keeps the elements and attributes allowed by the policy.
+// writeText writes the raw source of a text token, an unfinished tag at the end of s is written as text.
+func (p *SanitizePolicy) sanitize(s string, writeText func(*strings.Builder, string)) string {
+	var (
+		b     strings.Builder
+		stack []string
+	)
+	z := htmlx.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case htmlx.ErrorToken:
+			if raw := z.Raw(); len(raw) > 0 {
+				writeText(&b, string(raw))
+			}
+			for i := len(stack) - 1; i >= 0; i-- {
+				b.WriteString(`</` + stack[i] + `>`)
+			}
+			return b.String()
+		case htmlx.TextToken:
+			writeText(&b, string(z.Raw()))
+		case htmlx.EndTagToken:
+			name, _ := z.TagName()
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] != string(name) {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					b.WriteString(`</` + stack[j] + `>`)
+				}
+				stack = stack[:i]
+				break
+			}
+		case htmlx.StartTagToken, htmlx.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			_, void := voidElements[tag]
+			if p.dropContent(tag) {
+				if tt == htmlx.StartTagToken && !void {
+					skipElement(z, tag)
+				}
+				continue
+			}
+			attrs, ok := p.Elements[tag]
+			if !ok {
+				continue
+			}
+			b.WriteString(`<` + tag)
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attr := string(key)
+				if !p.allowedAttr(attr, attrs) {
+					continue
+				}
+				switch attr {
+				case `href`, `src`, `cite`:
+					if !p.allowedURL(string(val)) {
+						continue
+					}
+				}
+				b.WriteString(` ` + attr + `="` + html.EscapeString(string(val)) + `"`)
+			}
+			b.WriteString(`>`)
+			if !void {
+				stack = append(stack, tag)
+			}
+		}
+		// comments and doctypes are dropped
+	}
+}
+
+// skipElement skips the tokens up to the end tag of the element (nested elements with the same name included).
+func skipElement(z *htmlx.Tokenizer, name string) {
+	depth := 1
+	for {
+		switch z.Next() {
+		case htmlx.ErrorToken:
+			return
+		case htmlx.StartTagToken:
+			if n, _ := z.TagName(); string(n) == name {
+				depth++
+			}
+		case htmlx.EndTagToken:
+			if n, _ := z.TagName(); string(n) == name {
+				depth--
+				if depth == 0 {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *SanitizePolicy) dropContent(name string) bool {
+	for _, v := range p.DropContent {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SanitizePolicy) allowedAttr(name string, attrs []string) bool {
+	for _, v := range attrs {
+		if v == name {
+			return true
+		}
+	}
+	for _, v := range p.GlobalAttrs {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedURL reports whether the URL is relative or uses an allowed scheme.
+// Control characters and spaces are ignored as browsers do ("java\tscript:").
+func (p *SanitizePolicy) allowedURL(u string) bool {
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	pos := strings.IndexAny(u, `:/?#`)
+	if pos < 0 || u[pos] != ':' {
+		return true
+	}
+	return p.allowedScheme(u[:pos])
+}
+
+func (p *SanitizePolicy) allowedScheme(scheme string) bool {
+	for _, v := range p.URLSchemes {
+		if strings.EqualFold(v, scheme) {
+			return true
+		}
+	}
+	return false
+}
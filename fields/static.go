@@ -19,7 +19,6 @@
 package fields
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/coscms/forms/common"
@@ -36,7 +35,7 @@ func StaticField(name, content string) *Field {
 func StaticFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
 	var ret *Field
 	if useFieldValue {
-		ret = StaticField(name, fmt.Sprintf("%s", val.Field(fieldNo).Interface()))
+		ret = StaticField(name, instanceString(val, fieldNo))
 	} else {
 		ret = StaticField(name, common.TagVal(t, fieldNo, "form_value"))
 	}
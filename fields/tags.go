@@ -0,0 +1,138 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// TagsSeparator separates the tags of a tags field in its text value (the no-JS fallback of the token input).
+const TagsSeparator = `,`
+
+// TagsField creates a tags (token input) field with the given name and tags. Choices of a tags field are suggested
+// in a <datalist> and, when set, they are the only allowed tags.
+func TagsField(name string, tags ...string) *Field {
+	ret := FieldWithType(name, common.TAGS)
+	ret.Choices = []InputChoice{}
+	ret.SetTagValues(tags...)
+	return ret
+}
+
+// TagsFieldFromInstance creates and initializes a tags field based on its name, the reference object instance and field number.
+// The model field is a []string or a string of tags separated by commas. This method looks for "form_max" (maximum number of tags),
+// "form_maxlength" (maximum length of a tag), "form_choices" (allowed tags, a list of <id>|<value> pairs) and "form_value" tags.
+// The returned field is usable, invalid tags are ignored and returned as an error.
+func TagsFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, args ...func(string) string) (*Field, error) {
+	ret := TagsField(name, SplitTags(InstanceValues(val, t, fieldNo, useFieldValue)...)...)
+	maxCount, maxLength, err := ParseTagsLimit(common.TagVal(t, fieldNo, "form_max"), common.TagVal(t, fieldNo, "form_maxlength"))
+	ret.SetTagsLimit(maxCount, maxLength)
+	if err != nil {
+		err = fmt.Errorf("invalid tags limit for field %s: %w", name, err)
+	}
+	if tag := common.TagVal(t, fieldNo, "form_choices"); len(tag) > 0 {
+		if _, ok := ChoiceProviderName(tag); !ok {
+			fn := common.LabelFn
+			if len(args) > 0 {
+				fn = args[0]
+			}
+			choices := strings.Split(tag, "|")
+			list := make([]InputChoice, 0, len(choices)/2)
+			for i, j := 0, len(choices)-1; i < j; i += 2 {
+				list = append(list, InputChoice{ID: choices[i], Val: fn(choices[i+1])})
+			}
+			ret.SetChoices(list)
+		}
+	}
+	return ret, err
+}
+
+// ParseTagsLimit parses the maximum number of tags and the maximum length of a tag ("" or "0" is no limit).
+func ParseTagsLimit(maxCount string, maxLength string) (int, int, error) {
+	var errs []error
+	parse := func(key string, v string) int {
+		if len(v) == 0 {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs = append(errs, fmt.Errorf("%s must be a non-negative integer, got %q", key, v))
+			return 0
+		}
+		return n
+	}
+	count := parse("max", maxCount)
+	length := parse("maxlength", maxLength)
+	return count, length, errors.Join(errs...)
+}
+
+// SplitTags splits values on commas, trims spaces and removes empty and duplicate tags.
+func SplitTags(values ...string) []string {
+	var tags []string
+	seen := map[string]struct{}{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, TagsSeparator) {
+			tag = strings.TrimSpace(tag)
+			if len(tag) == 0 {
+				continue
+			}
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ValueTags returns the tags of a model value: a []string or a string of tags separated by commas.
+func ValueTags(v interface{}) []string {
+	return SplitTags(formatValue(v))
+}
+
+// SetTagValues sets the tags of a tags field.
+func (f *Field) SetTagValues(tags ...string) *Field {
+	f.SetValue(strings.Join(tags, TagsSeparator+` `))
+	return f
+}
+
+// TagValues returns the tags of a tags field.
+func (f *Field) TagValues() []string {
+	return SplitTags(f.Value)
+}
+
+// SetTagsLimit sets the maximum number of tags and the maximum length of a tag of a tags field, 0 is no limit.
+func (f *Field) SetTagsLimit(maxCount int, maxLength int) *Field {
+	f.Additional["maxTags"] = maxCount
+	f.Additional["maxLength"] = maxLength
+	return f
+}
+
+// TagsLimit returns the maximum number of tags and the maximum length of a tag of a tags field.
+func (f *Field) TagsLimit() (maxCount int, maxLength int) {
+	maxCount, _ = f.Additional["maxTags"].(int)
+	maxLength, _ = f.Additional["maxLength"].(int)
+	return
+}
@@ -19,6 +19,7 @@
 package fields
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -98,13 +99,13 @@ func TextFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name
 	ret := TextField(name, typ...)
 	if useFieldValue {
 		if dateFormat := common.TagVal(t, fieldNo, "form_format"); len(dateFormat) > 0 {
-			if vt, isEmpty := ConvertTime(val.Field(fieldNo).Interface()); !vt.IsZero() {
+			if vt, isEmpty := ConvertTime(instanceValue(val, fieldNo)); !vt.IsZero() {
 				ret.SetValue(vt.Format(dateFormat))
 			} else if isEmpty {
 				ret.SetValue(``)
 			}
 		} else {
-			ret.SetValue(fmt.Sprintf("%v", val.Field(fieldNo).Interface()))
+			ret.SetValue(instanceString(val, fieldNo))
 		}
 	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
 		ret.SetValue(v)
@@ -116,44 +117,54 @@ func TextFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name
 func PasswordFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
 	ret := PasswordField(name)
 	if useFieldValue {
-		ret.SetValue(fmt.Sprintf("%s", val.Field(fieldNo).String()))
+		ret.SetValue(instanceString(val, fieldNo))
 	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
 		ret.SetValue(v)
 	}
 	return ret
 }
 
-// TextFieldFromInstance creates and initializes a text field based on its name, the reference object instance and field number.
+// TextAreaFieldFromInstance creates and initializes a textarea field based on its name, the reference object instance and field number.
 // This method looks for "form_rows" and "form_cols" tags to add additional parameters to the field.
+// Invalid "form_rows" or "form_cols" tags are ignored, use TextAreaFieldFromInstanceE to get an error instead.
 func TextAreaFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
+	ret, _ := TextAreaFieldFromInstanceE(val, t, fieldNo, name, useFieldValue)
+	return ret
+}
+
+// TextAreaFieldFromInstanceE is like TextAreaFieldFromInstance but returns an error for invalid "form_rows" or "form_cols" tags.
+// The returned field is usable, the default size (20 rows, 50 cols) is used for the invalid tags.
+func TextAreaFieldFromInstanceE(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) (*Field, error) {
 	var rows, cols int = 20, 50
-	var err error
+	var errs []error
 	if v := common.TagVal(t, fieldNo, "form_rows"); len(v) > 0 {
-		rows, err = strconv.Atoi(v)
-		if err != nil {
-			return nil
+		if n, err := strconv.Atoi(v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid form_rows %q for field %s: %w", v, name, err))
+		} else {
+			rows = n
 		}
 	}
 	if v := common.TagVal(t, fieldNo, "form_cols"); len(v) > 0 {
-		cols, err = strconv.Atoi(v)
-		if err != nil {
-			return nil
+		if n, err := strconv.Atoi(v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid form_cols %q for field %s: %w", v, name, err))
+		} else {
+			cols = n
 		}
 	}
 	ret := TextAreaField(name, rows, cols)
 	if useFieldValue {
-		ret.SetText(fmt.Sprintf("%s", val.Field(fieldNo).String()))
+		ret.SetText(instanceString(val, fieldNo))
 	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
 		ret.SetText(v)
 	}
-	return ret
+	return ret, errors.Join(errs...)
 }
 
 // HiddenFieldFromInstance creates and initializes a hidden field based on its name, the reference object instance and field number.
 func HiddenFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool) *Field {
 	ret := HiddenField(name)
 	if useFieldValue {
-		ret.SetValue(fmt.Sprintf("%v", val.Field(fieldNo).Interface()))
+		ret.SetValue(instanceString(val, fieldNo))
 	} else if v := common.TagVal(t, fieldNo, "form_value"); len(v) > 0 {
 		ret.SetValue(v)
 	}
@@ -0,0 +1,189 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package fields
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/forms/common"
+)
+
+// DefaultRatingMax is the number of stars of rating fields without "form_max".
+const DefaultRatingMax = 5
+
+// SwitchField creates a toggle switch (a checkbox submitting "1" when it is on) with the given name and state.
+// Optional labels are the texts of the on and off states.
+func SwitchField(name string, checked bool, labels ...string) *Field {
+	ret := FieldWithType(name, common.SWITCH)
+	ret.SetValue(strconv.FormatBool(checked))
+	ret.SetLabels(labels...)
+	return ret
+}
+
+// SwitchFieldFromInstance creates and initializes a switch field based on its name, the reference object instance and field number.
+// This method looks for "form_labels" (ex: "On|Off") and "form_value" tags; the "checked" option turns the switch on by default.
+func SwitchFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, options map[string]struct{}, args ...func(string) string) *Field {
+	_, checked := options["checked"]
+	if !checked {
+		checked = IsChecked(defaultValue(val, t, fieldNo, useFieldValue))
+	}
+	ret := SwitchField(name, checked)
+	ret.SetLabels(tagLabels(t, fieldNo, args...)...)
+	return ret
+}
+
+// IsChecked reports whether the value of a switch field means "on" (1, true, on, yes...).
+func IsChecked(value string) bool {
+	switch strings.ToLower(value) {
+	case `on`, `yes`, `y`:
+		return true
+	}
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+// RatingField creates a rating field (stars from 1 to max rendered as radio buttons) with the given name.
+func RatingField(name string, max int) *Field {
+	ret := FieldWithType(name, common.RATING)
+	ret.SetRatingMax(max)
+	return ret
+}
+
+// RatingFieldFromInstance creates and initializes a rating field based on its name, the reference object instance and field number.
+// This method looks for "form_max" (number of stars), "form_labels" (titles of the stars, ex: "Poor|Fair|Good") and "form_value" tags.
+// The returned field is usable, an invalid "form_max" is ignored and returned as an error.
+func RatingFieldFromInstance(val reflect.Value, t reflect.Type, fieldNo int, name string, useFieldValue bool, args ...func(string) string) (*Field, error) {
+	var err error
+	max := DefaultRatingMax
+	if v := common.TagVal(t, fieldNo, "form_max"); len(v) > 0 {
+		if n, e := strconv.Atoi(v); e != nil || n < 1 {
+			err = fmt.Errorf("invalid form_max %q for rating field %s, expected a positive integer", v, name)
+		} else {
+			max = n
+		}
+	}
+	ret := RatingField(name, max)
+	ret.SetLabels(tagLabels(t, fieldNo, args...)...)
+	if v := defaultValue(val, t, fieldNo, useFieldValue); v != `0` {
+		ret.SetValue(v)
+	}
+	return ret, err
+}
+
+// SetRatingMax sets the number of stars of a rating field.
+func (f *Field) SetRatingMax(max int) *Field {
+	f.Additional["max"] = max
+	return f
+}
+
+// RatingMax returns the number of stars of a rating field.
+func (f *Field) RatingMax() int {
+	if max, ok := f.Additional["max"].(int); ok && max > 0 {
+		return max
+	}
+	return DefaultRatingMax
+}
+
+// RatingStar is a star of a rating field.
+type RatingStar struct {
+	Value   int
+	Label   string
+	Checked bool
+}
+
+// RatingStars returns the stars of a rating field, from 1 to RatingMax.
+func (f *Field) RatingStars() []RatingStar {
+	max := f.RatingMax()
+	labels := f.Labels()
+	value, _ := strconv.Atoi(f.Value)
+	stars := make([]RatingStar, max)
+	for i := range stars {
+		stars[i] = RatingStar{Value: i + 1, Label: strconv.Itoa(i + 1), Checked: value == i+1}
+		if i < len(labels) && len(labels[i]) > 0 {
+			stars[i].Label = labels[i]
+		}
+	}
+	return stars
+}
+
+// SetLabels sets the texts of the states of a switch field (on, off) or the titles of the stars of a rating field.
+func (f *Field) SetLabels(labels ...string) *Field {
+	if len(labels) == 0 {
+		delete(f.Additional, "labels")
+		return f
+	}
+	f.Additional["labels"] = labels
+	return f
+}
+
+// Labels returns the labels set by SetLabels.
+func (f *Field) Labels() []string {
+	labels, _ := f.Additional["labels"].([]string)
+	return labels
+}
+
+// SetRangeOutput displays the current value of a range field in an <output> next to the slider.
+// It is the same as SetData("output", on), which is how configuration files enable it.
+func (f *Field) SetRangeOutput(on bool) *Field {
+	f.SetData("output", on)
+	return f
+}
+
+// RangeOutput reports whether the current value of a range field is displayed.
+func (f *Field) RangeOutput() bool {
+	on, _ := f.AppendData["output"].(bool)
+	return on
+}
+
+// tagLabels returns the translated "form_labels" tag values.
+func tagLabels(t reflect.Type, fieldNo int, args ...func(string) string) []string {
+	tag := common.TagVal(t, fieldNo, "form_labels")
+	if len(tag) == 0 {
+		return nil
+	}
+	fn := common.LabelFn
+	if len(args) > 0 {
+		fn = args[0]
+	}
+	labels := strings.Split(tag, "|")
+	for i, v := range labels {
+		labels[i] = fn(v)
+	}
+	return labels
+}
+
+// toggleData adds the template data of switch and rating fields.
+func (f *Field) toggleData(data map[string]interface{}) {
+	switch f.Type {
+	case common.SWITCH:
+		data["checked"] = IsChecked(f.Value)
+		labels := f.Labels()
+		if len(labels) > 0 {
+			data["onLabel"] = labels[0]
+		}
+		if len(labels) > 1 {
+			data["offLabel"] = labels[1]
+		}
+	case common.RATING:
+		data["stars"] = f.RatingStars()
+	}
+}
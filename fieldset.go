@@ -46,6 +46,8 @@ type FieldSetType struct {
 	Template   string                 `json:"template" xml:"template"`
 	fieldMap   map[string]int
 	data       map[string]interface{}
+
+	addressParts map[string]*fields.Field // 地址组合字段的各部分（包括当前国家不显示的部分）
 }
 
 func (f *FieldSetType) Cols() int {
@@ -98,6 +100,14 @@ func (f *FieldSetType) Data() map[string]interface{} {
 	for k, v := range f.AppendData {
 		f.data[k] = v
 	}
+	if f.addressParts != nil {
+		// 地址组合字段的脚本通过名称查找各部分（在 langset 中名称可能已改变）
+		names := make(map[string]string, len(f.addressParts))
+		for part, field := range f.addressParts {
+			names[part] = field.Name()
+		}
+		f.data["addressNames"] = names
+	}
 	return f.data
 }
 
@@ -162,6 +172,12 @@ func (f *FieldSetType) Clone() config.FormElement {
 	for k, v := range f.data {
 		fc.data[k] = v
 	}
+	if f.addressParts != nil {
+		fc.addressParts = make(map[string]*fields.Field, len(f.addressParts))
+		for k, v := range f.addressParts {
+			fc.addressParts[k] = v
+		}
+	}
 	return &fc
 }
 
@@ -24,14 +24,17 @@ package forms
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
-	"net/url"
 	"path"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coscms/forms/common"
 	"github.com/coscms/forms/config"
@@ -120,6 +123,17 @@ type Form struct {
 	debug                 bool
 	data                  map[string]interface{}
 	structFieldConverter  func(string) string
+	ctx                   context.Context
+	lang                  string
+	location              *time.Location
+	buildErrors           BuildErrors
+	expandErrors          BuildErrors // Init 中无法展开的 subform 的错误
+	collectErrors         bool
+	authorizer            Authorizer
+	strictBind            bool
+	strictBindIgnore      []string
+	remoteURL             string
+	clientValidate        bool
 }
 
 func (f *Form) Reset() *Form {
@@ -146,6 +160,16 @@ func (f *Form) Reset() *Form {
 	f.debug = false
 	f.data = map[string]interface{}{}
 	f.structFieldConverter = nil
+	f.ctx = nil
+	f.lang = ``
+	f.location = nil
+	f.buildErrors = nil
+	f.expandErrors = nil
+	f.authorizer = nil
+	f.strictBind = false
+	f.strictBindIgnore = nil
+	f.remoteURL = ``
+	f.clientValidate = false
 	return f
 }
 
@@ -163,6 +187,40 @@ func (f *Form) SetStructFieldConverter(convert func(string) string) *Form {
 	return f
 }
 
+// SetContext 设置上下文（用于 ChoiceProvider 等）
+func (f *Form) SetContext(ctx context.Context) *Form {
+	f.ctx = ctx
+	return f
+}
+
+func (f *Form) Context() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+// SetLang 设置当前语言（用于 ChoiceProvider 等）
+func (f *Form) SetLang(lang string) *Form {
+	f.lang = lang
+	return f
+}
+
+func (f *Form) Lang() string {
+	return f.lang
+}
+
+// SetLocation 设置时区，日期和时间字段的值会转换为该时区显示，提交的值也按该时区解析
+func (f *Form) SetLocation(loc *time.Location) *Form {
+	f.location = loc
+	return f
+}
+
+// Location 返回表单的时区（为 nil 时按时间值本身的时区显示，按 time.Local 解析）
+func (f *Form) Location() *time.Location {
+	return f.location
+}
+
 func (f *Form) Config() *config.Config {
 	return f.config
 }
@@ -251,12 +309,15 @@ func (f *Form) Init(c *config.Config, model ...interface{}) *Form {
 	if c == nil {
 		c = &config.Config{}
 	}
-	f.config = c
-	if len(c.Theme) == 0 {
-		c.Theme = common.BASE
+	f.config, f.expandErrors = expandConfig(c)
+	for _, err := range f.expandErrors {
+		f.addBuildError(err.Field, err.Err)
 	}
 	f.ID = c.ID
 	f.Theme = c.Theme
+	if len(f.Theme) == 0 {
+		f.Theme = common.BASE
+	}
 	f.Method = c.Method
 	f.Action = template.HTML(c.Action)
 	if len(model) > 0 {
@@ -367,7 +428,7 @@ func (f *Form) ParseModel(model ...interface{}) *Form {
 	if m == nil {
 		m = f.Model
 	}
-	flist, fsort := f.unWindStructure(m, ``)
+	flist, fsort := f.unWindStructure(reflect.ValueOf(m), ``)
 	for _, v := range flist {
 		f.Elements(v.(config.FormElement))
 	}
@@ -434,96 +495,241 @@ func (f *Form) GenChoices(lenType interface{}, fnType interface{}) interface{} {
 	return GenChoices(lenType, fnType)
 }
 
-func (form *Form) unWindStructure(m interface{}, baseName string) ([]interface{}, string) {
-	t := reflect.TypeOf(m)
-	v := reflect.ValueOf(m)
+// applyChoiceProvider 使用已注册的选项提供者设置字段的选项，并选中 selected 中的值
+func (form *Form) applyChoiceProvider(f fields.FieldInterface, provider string, selected ...string) {
+	field, ok := f.(*fields.Field)
+	if !ok {
+		return
+	}
+	switch field.Type {
+	case common.SELECT, common.RADIO, common.CHECKBOX, common.TAGS:
+	default:
+		if !fields.IsDatalistType(field.Type) {
+			return
+		}
+	}
+	choices, err := fields.ProvideChoices(form.Context(), provider, form.Lang())
+	if err != nil {
+		form.addBuildError(field.OriginalName(), err)
+		return
+	}
+	field.SetChoicesFromConfig(choices, form.labelFn)
+	if len(selected) > 0 {
+		field.SetSelected(selected...)
+	}
+}
+
+// applyDatalist 根据 form_datalist 标签设置文本类输入框的输入建议
+func (form *Form) applyDatalist(f fields.FieldInterface, tag string) {
+	field, ok := f.(*fields.Field)
+	if !ok || !fields.IsDatalistType(field.Type) {
+		return
+	}
+	if provider, ok := fields.ChoiceProviderName(tag); ok {
+		form.applyChoiceProvider(field, provider)
+		return
+	}
+	field.SetDatalist(tag)
+}
+
+// applyLookup 设置 autocomplete 字段的选项查询函数，并通过它获取已选值的显示文本
+func (form *Form) applyLookup(f *fields.Field, lookup string) {
+	if len(lookup) == 0 {
+		return
+	}
+	f.SetLookup(lookup)
+	if len(f.Value) == 0 {
+		return
+	}
+	label, err := fields.LookupChoice(form.Context(), lookup, form.Lang(), f.Value)
+	if err != nil {
+		if !errors.Is(err, fields.ErrChoiceNotFound) {
+			form.addBuildError(f.OriginalName(), err)
+		}
+		return
+	}
+	f.SetSelectedText(label)
+}
+
+// validLookup 通过选项查询函数验证提交的值是否存在
+func (form *Form) validLookup(name string, lookup string, vals []string) error {
+	for _, val := range vals {
+		if len(val) == 0 {
+			continue
+		}
+		if _, err := fields.LookupChoice(form.Context(), lookup, form.Lang(), val); err != nil {
+			if errors.Is(err, fields.ErrChoiceNotFound) {
+				form.Validate().SetError(name, fields.ErrChoiceNotFound.Error())
+			} else {
+				form.Validate().SetError(name, ErrInvalidValue.Error())
+			}
+			return fmt.Errorf(`%s: %w`, name, err)
+		}
+	}
+	return nil
+}
+
+// keyValueFieldSet 为 map 类型的字段生成键值对输入框，每行包含名为“<name>.key”和“<name>.value”的两个输入框，末尾附加一个空行用于添加新的键值对
+func (form *Form) keyValueFieldSet(value reflect.Value, name string, label string, useFieldValue bool) *FieldSetType {
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	var rows [][2]string
+	if useFieldValue && value.Kind() == reflect.Map {
+		for _, key := range value.MapKeys() {
+			rows = append(rows, [2]string{fmt.Sprint(key.Interface()), fmt.Sprint(value.MapIndex(key).Interface())})
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i][0] < rows[j][0]
+		})
+	}
+	rows = append(rows, [2]string{})
+	elems := make([]config.FormElement, 0, len(rows)*2)
+	for _, row := range rows {
+		key := fields.TextField(name + `.key`)
+		key.SetValue(row[0])
+		key.SetParam(`placeholder`, form.labelFn(`Key`))
+		key.SetTheme(form.Theme)
+		val := fields.TextField(name + `.value`)
+		val.SetValue(row[1])
+		val.SetParam(`placeholder`, form.labelFn(`Value`))
+		val.SetTheme(form.Theme)
+		elems = append(elems, key, val)
+	}
+	if label != `-` {
+		label = form.labelFn(label)
+	} else {
+		label = ``
+	}
+	return form.NewFieldSet(name, label, elems...)
+}
+
+func (form *Form) unWindStructure(v reflect.Value, baseName string, parents ...reflect.Type) ([]interface{}, string) {
+	t := v.Type()
 	if t.Kind() == reflect.Pointer {
 		t = t.Elem()
-		v = v.Elem()
+		if v.IsNil() {
+			v = reflect.New(t).Elem()
+		} else {
+			v = v.Elem()
+		}
 	}
 	var (
 		fieldList []interface{}
 		fieldSort string
 	)
+	plan := getStructPlan(t)
+	parents = append(parents, t)
 	fieldSetList := map[string]*FieldSetType{}
 	fieldSetSort := map[string]string{}
-	for i := 0; i < t.NumField(); i++ {
-		if !v.Field(i).CanInterface() {
-			continue
-		}
-		options := make(map[string]struct{})
-		tag, tagf := common.Tag(t, t.Field(i), "form_options")
-		if len(tag) > 0 {
-			var optionsArr []string
-			if tagf != nil {
-				cached := tagf.Parsed("form_options", func() interface{} {
-					return strings.Split(common.TagVal(t, i, "form_options"), ";")
-				})
-				optionsArr = cached.([]string)
-			}
-			for _, opt := range optionsArr {
-				if len(opt) > 0 {
-					options[opt] = struct{}{}
-				}
-			}
-			if _, ok := options["-"]; ok {
-				continue
-			}
-		}
-		widget := common.TagVal(t, i, "form_widget")
+	for _, fp := range plan.Fields {
+		i := fp.Index
 		var f fields.FieldInterface
 		var fName string
 		if len(baseName) == 0 {
-			fName = t.Field(i).Name
+			fName = fp.Name
 		} else {
-			fName = baseName + "." + t.Field(i).Name
+			fName = baseName + "." + fp.Name
 		}
 		useFieldValue := !form.IsOmit(fName)
+		path := fName
 		//fmt.Println(fName, t.Field(i).Type.String(), t.Field(i).Type.Kind())
 		if form.nameFn != nil {
 			fName = form.nameFn(fName)
 		}
-		switch widget {
-		case "color", "email", "file", "image", "month", "search", "tel", "url", "week":
-			f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue, widget)
+		access := form.authorize(fp.element(path))
+		if access == AccessHidden {
+			continue
+		}
+		switch fp.Widget {
+		case "color", "email", "file", "image", "search", "tel", "url":
+			f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue, fp.Widget)
 		case "text":
 			f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
 		case "hidden":
 			f = fields.HiddenFieldFromInstance(v, t, i, fName, useFieldValue)
 		case "textarea":
-			f = fields.TextAreaFieldFromInstance(v, t, i, fName, useFieldValue)
+			ff, err := fields.TextAreaFieldFromInstanceE(v, t, i, fName, useFieldValue)
+			form.addBuildError(fName, err)
+			f = ff
+		case "richtext", "markdown":
+			ff, err := fields.EditorFieldFromInstance(v, t, i, fName, useFieldValue, fp.Widget)
+			form.addBuildError(fName, err)
+			f = ff
 		case "password":
 			f = fields.PasswordFieldFromInstance(v, t, i, fName, useFieldValue)
 		case "select":
-			f = fields.SelectFieldFromInstance(v, t, i, fName, useFieldValue, options, form.labelFn)
-		case "date":
-			f = fields.DateFieldFromInstance(v, t, i, fName, useFieldValue)
-		case "datetime":
-			f = fields.DatetimeFieldFromInstance(v, t, i, fName, useFieldValue)
-		case "time":
-			f = fields.TimeFieldFromInstance(v, t, i, fName, useFieldValue)
+			f = fields.SelectFieldFromInstance(v, t, i, fName, useFieldValue, fp.Options, form.labelFn)
+		case "date", "datetime", "datetime-local", "time", "month", "week":
+			ff, err := fields.TemporalFieldFromInstanceE(v, t, i, fName, useFieldValue, fp.Widget, form.location)
+			form.addBuildError(fName, err)
+			f = ff
+		case "money", "decimal":
+			ff, err := fields.DecimalFieldFromInstance(v, t, i, fName, useFieldValue, fp.Widget)
+			form.addBuildError(fName, err)
+			ff.SetLocale(form.Lang())
+			f = ff
 		case "number":
 			f = fields.NumberFieldFromInstance(v, t, i, fName, useFieldValue)
 		case "range":
-			f = fields.RangeFieldFromInstance(v, t, i, fName, useFieldValue)
+			ff := fields.RangeFieldFromInstance(v, t, i, fName, useFieldValue, form.labelFn)
+			if _, ok := fp.Options["output"]; ok {
+				ff.SetRangeOutput(true)
+			}
+			f = ff
+		case "tags":
+			ff, err := fields.TagsFieldFromInstance(v, t, i, fName, useFieldValue, form.labelFn)
+			form.addBuildError(fName, err)
+			f = ff
+		case "switch":
+			f = fields.SwitchFieldFromInstance(v, t, i, fName, useFieldValue, fp.Options, form.labelFn)
+		case "rating":
+			ff, err := fields.RatingFieldFromInstance(v, t, i, fName, useFieldValue, form.labelFn)
+			form.addBuildError(fName, err)
+			f = ff
+		case "address":
+			if !fp.Nested {
+				form.addBuildError(fName, fmt.Errorf(`the address widget requires a struct field, got %s`, fp.Type))
+				f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
+				break
+			}
+			fieldList = append(fieldList, restrictElement(form.addressFieldSetFromInstance(v.Field(i), path, fp.Label, useFieldValue), access))
+			if len(fp.Sort) > 0 {
+				if len(fieldSort) == 0 {
+					fieldSort = fName + ":" + fp.Sort
+				} else {
+					fieldSort += "," + fName + ":" + fp.Sort
+				}
+			}
+			f = nil
 		case "radio":
 			f = fields.RadioFieldFromInstance(v, t, i, fName, useFieldValue, form.labelFn)
 		case "checkbox":
 			f = fields.CheckboxFieldFromInstance(v, t, i, fName, useFieldValue, form.labelFn)
 		case "static":
 			f = fields.StaticFieldFromInstance(v, t, i, fName, useFieldValue)
+		case "autocomplete":
+			ff := fields.AutocompleteFieldFromInstance(v, t, i, fName, useFieldValue)
+			form.applyLookup(ff, fp.Lookup)
+			f = ff
 		default:
-			switch t.Field(i).Type.String() {
-			case "string":
-				f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
-			case "bool":
-				f = fields.CheckboxFromInstance(v, t, i, fName, useFieldValue, options)
-			case "time.Time":
-				f = fields.DatetimeFieldFromInstance(v, t, i, fName, useFieldValue)
-			case "int", "int64", "float", "float32", "float64":
-				f = fields.NumberFieldFromInstance(v, t, i, fName, useFieldValue)
-			case "struct":
-				fl, fs := form.unWindStructure(v.Field(i).Interface(), fName)
+			switch {
+			case fp.Nested:
+				fv := v.Field(i)
+				if fv.Kind() == reflect.Pointer && fv.IsNil() && isParentType(parents, fp.ValueType) {
+					// 自引用类型的空指针无需展开
+					continue
+				}
+				nestedName := fName
+				if fp.Embedded {
+					nestedName = baseName
+				}
+				fl, fs := form.unWindStructure(fv, nestedName, parents...)
+				for _, elem := range fl {
+					if e, ok := elem.(config.FormElement); ok {
+						restrictElement(e, access)
+					}
+				}
 				if len(fs) > 0 {
 					if len(fieldSort) == 0 {
 						fieldSort = fs
@@ -533,66 +739,85 @@ func (form *Form) unWindStructure(m interface{}, baseName string) ([]interface{}
 				}
 				fieldList = append(fieldList, fl...)
 				f = nil
+			case fp.ValueType == timeType:
+				ff, err := fields.TemporalFieldFromInstanceE(v, t, i, fName, useFieldValue, common.DATETIME, form.location)
+				form.addBuildError(fName, err)
+				f = ff
 			default:
-				if t.Field(i).Type.Kind() == reflect.Struct ||
-					(t.Field(i).Type.Kind() == reflect.Pointer &&
-						t.Field(i).Type.Elem().Kind() == reflect.Struct) {
-					fl, fs := form.unWindStructure(v.Field(i).Interface(), fName)
-					if len(fs) > 0 {
+				switch fp.ValueType.Kind() {
+				case reflect.String:
+					if len(fp.Provider) > 0 {
+						f = fields.SelectFieldFromInstance(v, t, i, fName, useFieldValue, fp.Options, form.labelFn)
+					} else {
+						f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
+					}
+				case reflect.Bool:
+					f = fields.CheckboxFromInstance(v, t, i, fName, useFieldValue, fp.Options)
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+					reflect.Float32, reflect.Float64:
+					f = fields.NumberFieldFromInstance(v, t, i, fName, useFieldValue)
+				case reflect.Slice, reflect.Array:
+					if fp.Choices && fields.IsMultiValue(fp.Type) {
+						f = fields.SelectFieldFromInstance(v, t, i, fName, useFieldValue, fp.Options, form.labelFn)
+					} else {
+						f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
+					}
+				case reflect.Map:
+					fs := form.keyValueFieldSet(v.Field(i), fName, fp.Label, useFieldValue)
+					fieldList = append(fieldList, restrictElement(fs, access))
+					if len(fp.Sort) > 0 {
 						if len(fieldSort) == 0 {
-							fieldSort = fs
+							fieldSort = fName + ":" + fp.Sort
 						} else {
-							fieldSort += "," + fs
+							fieldSort += "," + fName + ":" + fp.Sort
 						}
 					}
-					fieldList = append(fieldList, fl...)
 					f = nil
-				} else {
+				default:
 					f = fields.TextFieldFromInstance(v, t, i, fName, useFieldValue)
 				}
 			}
 		}
+		if f != nil && len(fp.Provider) > 0 {
+			form.applyChoiceProvider(f, fp.Provider, fields.InstanceValues(v, t, i, useFieldValue)...)
+		}
+		if f != nil && len(fp.Datalist) > 0 {
+			form.applyDatalist(f, fp.Datalist)
+		}
 		if f != nil {
-			label := common.TagVal(t, i, "form_label")
-			if len(label) == 0 {
-				label = com.Title(t.Field(i).Name)
-			} else if label != `-` {
+			form.addBuildError(fName, fp.ParamsErr)
+			label := fp.Label
+			if label != `-` {
 				label = form.labelFn(label)
 			} else {
 				label = ``
 			}
 			f.SetLabel(label)
 
-			params := common.TagVal(t, i, "form_params")
-			if len(params) > 0 {
-				if paramsMap, err := url.ParseQuery(params); err == nil {
-					for k, v := range paramsMap {
-						if k == "placeholder" || k == "title" {
-							v[0] = form.labelFn(v[0])
-						}
-						f.SetParam(k, v[0])
-					}
-				} else {
-					log.Println(err)
+			for k, v := range fp.Params {
+				value := v[0]
+				if k == "placeholder" || k == "title" {
+					value = form.labelFn(value)
 				}
+				f.SetParam(k, value)
 			}
-			valid := common.TagVal(t, i, "valid")
-			if len(valid) > 0 {
-				form.validTagFn(valid, f)
-			}
-			fieldsetLabel := common.TagVal(t, i, "form_fieldset") // label;name or name
-			fieldsort := common.TagVal(t, i, "form_sort")         // 1 ( or other number ) or "last"
-			if len(fieldsetLabel) > 0 {
-				fieldsets := strings.SplitN(fieldsetLabel, ";", 2)
-				var fieldsetName string
-				switch len(fieldsets) {
-				case 1:
-					fieldsetName = fieldsets[0]
-				case 2:
-					fieldsetLabel = fieldsets[0]
-					fieldsetName = fieldsets[1]
+			if len(fp.Valid) > 0 {
+				form.validTagFn(fp.Valid, f)
+				if ff, ok := f.(*fields.Field); ok {
+					ff.SetValidRule(fp.Valid, fp.Filters)
 				}
-				fieldsetLabel = form.labelFn(fieldsetLabel)
+			}
+			if ff, ok := f.(*fields.Field); ok && len(fp.Mask) > 0 && fields.IsMaskType(ff.Type) {
+				ff.SetMask(fp.Mask)
+			}
+			form.addBuildError(fName, fields.CheckFilters(fp.Filters))
+			restrictElement(f, access)
+			// form_remote 只在 Bind 时验证：RemoteHandler 只能验证配置中的元素，不输出 data-remote
+			fieldsort := fp.Sort // 1 ( or other number ) or "last"
+			if len(fp.FieldsetName) > 0 {
+				fieldsetName := fp.FieldsetName
+				fieldsetLabel := form.labelFn(fp.FieldsetLabel)
 				f.SetData("container", "fieldset")
 				if _, ok := fieldSetList[fieldsetName]; !ok {
 					fieldSetList[fieldsetName] = form.NewFieldSet(fieldsetName, fieldsetLabel, f)
@@ -648,6 +873,12 @@ func (f *Form) Data() map[string]interface{} {
 		"method":    f.Method,
 		"action":    f.Action,
 	}
+	if scripts := f.renderScripts(); len(scripts) > 0 {
+		f.data["scripts"] = scripts
+	}
+	if f.ClientValidate() {
+		f.data["validator"] = f.renderClientValidate()
+	}
 	for k, v := range f.AppendData {
 		f.data[k] = v
 	}
@@ -661,6 +892,9 @@ func (f *Form) runBefore() {
 }
 
 func (f *Form) render() string {
+	if f.IsReadonly() {
+		return f.renderReadonly()
+	}
 	f.runBefore()
 	buf := bytes.NewBuffer(nil)
 	t, err := f.HTMLTemplate()
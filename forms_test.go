@@ -1,14 +1,25 @@
 package forms_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/coscms/forms"
 	"github.com/coscms/forms/common"
 	"github.com/coscms/forms/config"
 	_ "github.com/coscms/forms/defaults"
+	"github.com/coscms/forms/fields"
+	"github.com/stretchr/testify/assert"
 	"github.com/webx-top/com"
+	"github.com/webx-top/validation"
 )
 
 func TestForms(t *testing.T) {
@@ -89,3 +100,889 @@ func TestParseConfig(t *testing.T) {
 	f.ParseFromConfig(true)
 	com.Dump(cfg.Clone())
 }
+
+func TestPopulate(t *testing.T) {
+	cfg := forms.NewConfig()
+	cfg.Theme = common.BASE
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{
+		Type: `text`,
+		Name: `name`,
+	}, &config.Element{
+		Type: `textarea`,
+		Name: `content`,
+	}, &config.Element{
+		Type: `select`,
+		Name: `tags`,
+		Choices: []*config.Choice{
+			{Option: []string{`a`, `A`}},
+			{Option: []string{`b`, `B`}},
+			{Option: []string{`c`, `C`}},
+		},
+		Attributes: [][]string{{`multiple`}},
+	}, &config.Element{
+		Type: `checkbox`,
+		Name: `flags`,
+		Choices: []*config.Choice{
+			{Option: []string{`x`, `X`}, Checked: true},
+			{Option: []string{`y`, `Y`}},
+		},
+	}, &config.Element{
+		Type: `radio`,
+		Name: `show`,
+		Choices: []*config.Choice{
+			{Option: []string{`1`, `Show`}, Checked: true},
+			{Option: []string{`0`, `Hide`}},
+		},
+	})
+	form := forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	form.Populate(url.Values{
+		`name`:    {`webx`},
+		`content`: {`hello`},
+		`tags`:    {`a`, `c`},
+		`show`:    {`0`},
+	})
+	assert.Equal(t, `webx`, form.Field(`name`).(*fields.Field).Value)
+	assert.Equal(t, `hello`, form.Field(`content`).(*fields.Field).Additional[`text`])
+	tags := form.Field(`tags`).(*fields.Field).Choices.(fields.ChoiceGroups).Group(``).Choices
+	assert.True(t, tags[0].Checked)
+	assert.False(t, tags[1].Checked)
+	assert.True(t, tags[2].Checked)
+	flags := form.Field(`flags`).(*fields.Field).Choices.([]fields.InputChoice)
+	assert.False(t, flags[0].Checked)
+	assert.False(t, flags[1].Checked)
+	show := form.Field(`show`).(*fields.Field).Choices.([]fields.InputChoice)
+	assert.False(t, show[0].Checked)
+	assert.True(t, show[1].Checked)
+}
+
+type providerModel struct {
+	Country   string   `form_choices:"provider:test_countries"`
+	Languages []string `form_widget:"checkbox" form_choices:"provider:test_countries"`
+}
+
+func TestChoiceProvider(t *testing.T) {
+	fields.RegisterChoiceProvider(`test_countries`, func(ctx context.Context, lang string) ([]*config.Choice, error) {
+		return []*config.Choice{
+			{Option: []string{`cn`, lang + `:China`}},
+			{Option: []string{`us`, lang + `:USA`}},
+		}, nil
+	})
+	defer fields.UnregisterChoiceProvider(`test_countries`)
+
+	form := forms.New().SetLang(`en`)
+	form.ParseModel(&providerModel{Country: `us`, Languages: []string{`cn`, `us`}})
+	country := form.Field(`Country`).(*fields.Field)
+	assert.Equal(t, common.SELECT, country.Type)
+	choices := country.Choices.(fields.ChoiceGroups).Group(``).Choices
+	assert.Equal(t, `en:China`, choices[0].Val)
+	assert.False(t, choices[0].Checked)
+	assert.True(t, choices[1].Checked)
+	languages := form.Field(`Languages`).(*fields.Field).Choices.([]fields.InputChoice)
+	assert.True(t, languages[0].Checked)
+	assert.True(t, languages[1].Checked)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{
+		Type:     `radio`,
+		Name:     `country`,
+		Value:    `cn`,
+		Provider: `test_countries`,
+	})
+	form = forms.NewWithConfig(cfg).SetLang(`zh`)
+	form.ParseFromConfig()
+	radio := form.Field(`country`).(*fields.Field).Choices.([]fields.InputChoice)
+	assert.Equal(t, `zh:USA`, radio[1].Val)
+	assert.True(t, radio[0].Checked)
+}
+
+type lookupModel struct {
+	Customer string `form_widget:"autocomplete" form_url:"/customers/search" form_lookup:"test_customers"`
+}
+
+func TestChoiceLookup(t *testing.T) {
+	customers := map[string]string{`1`: `Alice`, `2`: `Bob`}
+	fields.RegisterChoiceLookup(`test_customers`, func(ctx context.Context, lang string, id string) (string, bool, error) {
+		label, ok := customers[id]
+		return label, ok, nil
+	})
+	defer fields.UnregisterChoiceLookup(`test_customers`)
+
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(&lookupModel{Customer: `2`})
+	customer := form.Field(`Customer`).(*fields.Field)
+	assert.Equal(t, common.AUTOCOMPLETE, customer.Type)
+	html := customer.String()
+	assert.Contains(t, html, `<input type="hidden" name="Customer" value="2">`)
+	assert.Contains(t, html, `data-autocomplete-url="/customers/search"`)
+	assert.Contains(t, html, `value="Bob">`)
+
+	m := &lookupModel{}
+	err := forms.New().Bind(url.Values{`Customer`: {`3`}}, m)
+	assert.ErrorIs(t, err, fields.ErrChoiceNotFound)
+	form = forms.New()
+	assert.NoError(t, form.Bind(url.Values{`Customer`: {`1`}}, m))
+	assert.Equal(t, `1`, m.Customer)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{
+		Type:   common.AUTOCOMPLETE,
+		Name:   `customer`,
+		URL:    `/customers/search`,
+		Lookup: `test_customers`,
+	})
+	form = forms.NewWithConfig(cfg, &struct{ Customer string }{Customer: `1`})
+	form.ParseFromConfig()
+	assert.Contains(t, form.Field(`customer`).String(), `value="Alice">`)
+	elem := form.Field(`customer`).(*fields.Field).Element()
+	assert.Equal(t, `test_customers`, elem.Lookup)
+	_, verr := form.Filter(url.Values{`customer`: {`9`}})
+	assert.NotNil(t, verr)
+
+	form = forms.NewWithConfig(cfg, &struct{ Customer string }{Customer: `1`})
+	form.ParseFromConfig()
+	form.Populate(url.Values{`customer`: {`2`}})
+	assert.Equal(t, `Bob`, form.Field(`customer`).(*fields.Field).Additional[`text`])
+	form.Populate(url.Values{`customer`: {`9`}})
+	assert.Equal(t, ``, form.Field(`customer`).(*fields.Field).Additional[`text`])
+	html = form.Field(`customer`).String()
+	assert.Contains(t, html, `value="9">`)
+	assert.NotContains(t, html, `value="Bob">`)
+}
+
+type datalistModel struct {
+	Color string `form_widget:"color" form_datalist:"#ff0000|#00ff00"`
+	City  string `form_datalist:"provider:test_cities"`
+}
+
+func TestDatalist(t *testing.T) {
+	fields.RegisterChoiceProvider(`test_cities`, func(ctx context.Context, lang string) ([]*config.Choice, error) {
+		return []*config.Choice{{Option: []string{`hz`, `Hangzhou`}}}, nil
+	})
+	defer fields.UnregisterChoiceProvider(`test_cities`)
+
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(&datalistModel{})
+	html := form.Field(`Color`).String()
+	assert.Contains(t, html, `list="datalist-color">`)
+	assert.Contains(t, html, "<datalist id=\"datalist-color\">\n    <option value=\"#ff0000\">\n    <option value=\"#00ff00\">\n</datalist>")
+	assert.Contains(t, form.Field(`City`).String(), `<option value="hz" label="Hangzhou">`)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{
+		ID:      `email`,
+		Type:    common.EMAIL,
+		Name:    `email`,
+		Choices: []*config.Choice{{Option: []string{`a@example.com`}}},
+	})
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	html = form.Field(`email`).String()
+	assert.Contains(t, html, `list="email-datalist">`)
+	assert.Contains(t, html, `<datalist id="email-datalist">`)
+	assert.Contains(t, html, `<option value="a@example.com">`)
+}
+
+type temporalModel struct {
+	Start time.Time `form_widget:"datetime-local" form_step:"1"`
+	Month time.Time `form_widget:"month"`
+	Week  time.Time `form_widget:"week" form_min:"2024-W01"`
+}
+
+func TestTemporalFields(t *testing.T) {
+	loc := time.FixedZone(`UTC+8`, 8*3600)
+	m := &temporalModel{
+		Start: time.Date(2024, 3, 1, 1, 2, 3, 0, time.UTC),
+		Month: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Week:  time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.SetLocation(loc)
+	form.ParseModel(m)
+	assert.Contains(t, form.Field(`Start`).String(), `value="2024-03-01T09:02:03"`)
+	assert.Contains(t, form.Field(`Month`).String(), `value="2024-03"`)
+	html := form.Field(`Week`).String()
+	assert.Contains(t, html, `value="2025-W01"`)
+	assert.Contains(t, html, `min="2024-W01"`)
+
+	err := form.Bind(url.Values{
+		`Start`: {`2024-05-06T07:08`},
+		`Month`: {`2024-05`},
+		`Week`:  {`2024-W10`},
+	}, m)
+	assert.NoError(t, err)
+	assert.True(t, m.Start.Equal(time.Date(2024, 5, 6, 7, 8, 0, 0, loc)))
+	assert.True(t, m.Month.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, loc)))
+	assert.True(t, m.Week.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, loc)))
+}
+
+type moneyModel struct {
+	Price  int64   `form_widget:"money" form_currency:"€" form_min:"0" form_max:"10000"`
+	Amount string  `form_widget:"decimal" form_precision:"3"`
+	Rate   float64 `form_widget:"decimal"`
+}
+
+func TestMoneyField(t *testing.T) {
+	fields.RegisterNumberFormat(`de`, fields.NumberFormat{Decimal: `,`, Thousands: `.`, CurrencyAfter: true})
+	m := &moneyModel{Price: 123450, Amount: `1000000.5`, Rate: 1e6}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.SetLang(`de-DE`)
+	form.ParseModel(m)
+	html := form.Field(`Price`).String()
+	assert.Contains(t, html, `value="1.234,50"`)
+	assert.Contains(t, html, `max="10000.00"`)
+	assert.Contains(t, html, "value=\"1.234,50\">\n<span class=\"currency\">€</span>")
+	assert.Contains(t, form.Field(`Amount`).String(), `value="1.000.000,500"`)
+	assert.Contains(t, form.Field(`Rate`).String(), `value="1.000.000,00"`)
+
+	err := form.Bind(url.Values{
+		`Price`:  {`2.000,75`},
+		`Amount`: {`-0,125`},
+		`Rate`:   {`3,5`},
+	}, m)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200075), m.Price)
+	assert.Equal(t, `-0.125`, m.Amount)
+	assert.Equal(t, 3.5, m.Rate)
+
+	err = form.Bind(url.Values{`Price`: {`10.000,01`}}, m)
+	assert.Error(t, err)
+	assert.Equal(t, `Maximum is 10.000,00`, form.Validate().ErrorMap()[`Price`].Message)
+	assert.Equal(t, int64(200075), m.Price)
+
+	form = forms.New()
+	form.Theme = common.BASE
+	form.SetLang(`de-DE`)
+	form.ParseModel(&moneyModel{})
+	form.Populate(url.Values{`Price`: {`abc`}, `Amount`: {`1.000`}, `Rate`: {`3,5`}})
+	assert.Contains(t, form.Field(`Price`).String(), `value="abc"`)
+	assert.Contains(t, form.Field(`Amount`).String(), `value="1.000,000"`)
+	assert.Contains(t, form.Field(`Rate`).String(), `value="3,50"`)
+}
+
+type articleModel struct {
+	Body  string `form_widget:"richtext"`
+	Notes string `form_widget:"markdown" form_policy:"test_plain"`
+}
+
+func TestRichText(t *testing.T) {
+	fields.RegisterSanitizePolicy(`test_plain`, &fields.SanitizePolicy{})
+	defer fields.UnregisterSanitizePolicy(`test_plain`)
+
+	m := &articleModel{}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	assert.Contains(t, form.Field(`Body`).String(), `<textarea name="Body" data-editor="richtext"`)
+	err := form.Bind(url.Values{
+		`Body`:  {`<p style="color:red">Hi<script>alert(1)</script></p>`},
+		`Notes`: {`*hi* <b>there</b>`},
+	}, m)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hi</p>`, m.Body)
+	assert.Equal(t, `*hi* there`, m.Notes)
+	form = forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	assert.Contains(t, form.Field(`Body`).String(), "\n&lt;p&gt;Hi&lt;/p&gt;</textarea>")
+	form = forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	form.Populate(url.Values{`Body`: {`<p>draft</p>`}})
+	assert.Contains(t, form.Field(`Body`).String(), "\n&lt;p&gt;draft&lt;/p&gt;</textarea>")
+
+	cfg := forms.NewConfig()
+	cfg.AddElement(&config.Element{Type: common.RICHTEXT, Name: `content`})
+	form = forms.NewWithConfig(cfg)
+	r, verr := form.Filter(url.Values{`content`: {`<img src=x onerror=alert(1)>`}})
+	assert.Nil(t, verr)
+	assert.Equal(t, `<img src="x">`, r.Get(`content`))
+}
+
+type preferenceModel struct {
+	Notify bool `form_widget:"switch" form_labels:"On|Off"`
+	Stars  int  `form_widget:"rating" form_max:"3" form_labels:"Bad|OK|Great"`
+	Volume int  `form_widget:"range" form_options:"output" form_choices:"0|Low|10|High"`
+}
+
+func TestToggleFields(t *testing.T) {
+	m := &preferenceModel{Notify: true, Stars: 2, Volume: 5}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	html := form.Field(`Notify`).String()
+	assert.Contains(t, html, `<input type="checkbox" role="switch" name="Notify" value="1" checked="checked"`)
+	assert.Contains(t, html, `data-label-on="On" data-label-off="Off">On</span>`)
+	html = form.Field(`Stars`).String()
+	assert.Contains(t, html, `value="2" checked="checked"><span>OK</span>`)
+	assert.NotContains(t, html, `value="4"`)
+	html = form.Field(`Volume`).String()
+	assert.Contains(t, html, `<output>5</output>`)
+	assert.Contains(t, html, `<option value="10" label="High">`)
+
+	err := form.Bind(url.Values{`Stars`: {`3`}, `Volume`: {`7`}}, m)
+	assert.NoError(t, err)
+	assert.False(t, m.Notify)
+	assert.Equal(t, 3, m.Stars)
+	assert.Equal(t, 7, m.Volume)
+	assert.NoError(t, form.Bind(url.Values{`Notify`: {`1`}}, m))
+	assert.True(t, m.Notify)
+
+	form = forms.New()
+	form.Theme = common.BOOTSTRAP
+	form.ParseModel(m)
+	form.Populate(url.Values{`Stars`: {`1`}})
+	assert.NotContains(t, form.Field(`Notify`).String(), `checked="checked"`)
+	assert.Contains(t, form.Field(`Stars`).String(), `value="1" checked="checked"> Bad`)
+	elem := form.Field(`Stars`).(*fields.Field).Element()
+	assert.Equal(t, []string{`Bad`, `OK`, `Great`}, elem.Labels)
+	assert.Equal(t, `3`, elem.Attr(`max`))
+}
+
+type postModel struct {
+	Keywords []string `form_widget:"tags" form_max:"3" form_maxlength:"8"`
+	Topics   string   `form_widget:"tags" form_choices:"go|Go|js|JavaScript"`
+}
+
+func TestTagsField(t *testing.T) {
+	m := &postModel{Keywords: []string{`a`, `b`}, Topics: `go`}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	assert.Contains(t, form.Field(`Keywords`).String(), `data-max-tags="3" data-max-length="8" value="a, b">`)
+	assert.Contains(t, form.Field(`Topics`).String(), `<option value="js" label="JavaScript">`)
+	assert.NotContains(t, form.Field(`Keywords`).String(), `<script>`)
+	// 脚本在表单中只输出一次
+	html := string(form.Render())
+	assert.Equal(t, 1, strings.Count(html, `function tagsInput(input)`))
+
+	err := form.Bind(url.Values{`Keywords`: {`x, y,,x`, `z`}, `Topics`: {`go, js`}}, m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`x`, `y`, `z`}, m.Keywords)
+	assert.Equal(t, `go,js`, m.Topics)
+
+	err = form.Bind(url.Values{`Keywords`: {`a,b,c,d`}}, m)
+	assert.Error(t, err)
+	assert.Equal(t, `Maximum size is 3`, form.Validate().ErrorMap()[`Keywords`].Message)
+	assert.Error(t, form.Bind(url.Values{`Topics`: {`rust`}}, m))
+	assert.Equal(t, `go,js`, m.Topics)
+
+	elem := form.Field(`Keywords`).(*fields.Field).Element()
+	assert.Equal(t, `3`, elem.Attr(`max`))
+	assert.Equal(t, `8`, elem.Attr(`maxlength`))
+
+	// 选项提供者出错时拒绝提交的值
+	fields.RegisterChoiceProvider(`test_topics`, func(ctx context.Context, lang string) ([]*config.Choice, error) {
+		return nil, errors.New(`database down`)
+	})
+	defer fields.UnregisterChoiceProvider(`test_topics`)
+	pm := &struct {
+		Topics []string `form_widget:"tags" form_choices:"provider:test_topics"`
+	}{}
+	err = forms.New().Bind(url.Values{`Topics`: {`anything`}}, pm)
+	assert.ErrorContains(t, err, `database down`)
+	assert.Empty(t, pm.Topics)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{Type: common.TAGS, Name: `topics`, Provider: `test_topics`})
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	err = form.Bind(url.Values{`topics`: {`anything`}}, pm)
+	assert.ErrorContains(t, err, `database down`)
+	assert.Empty(t, pm.Topics)
+}
+
+type contactModel struct {
+	Phone string `form_widget:"tel" form_mask:"(999) 999-9999" valid:"numeric"`
+}
+
+func TestInputMask(t *testing.T) {
+	m := &contactModel{}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	html := form.Field(`Phone`).String()
+	assert.Contains(t, html, `pattern="\([0-9][0-9][0-9]\) [0-9][0-9][0-9]-[0-9][0-9][0-9][0-9]"`)
+	assert.Contains(t, html, `data-mask="(999) 999-9999">`)
+	assert.NotContains(t, html, `<script>`)
+	assert.Equal(t, 1, strings.Count(string(form.Render()), `function maskInput(input)`))
+	assert.NoError(t, form.Bind(url.Values{`Phone`: {`(555) 123-4567`}}, m))
+	assert.Equal(t, `5551234567`, m.Phone)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{
+		Type:  common.TEL,
+		Name:  `phone`,
+		Mask:  `+1 999-9999`,
+		Valid: `numeric`,
+	})
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	values, err := form.Filter(url.Values{`phone`: {`+1 555-1234`}})
+	assert.Nil(t, err)
+	assert.Equal(t, `5551234`, values.Get(`phone`))
+	assert.Equal(t, `+1 999-9999`, form.Field(`phone`).(*fields.Field).Element().Mask)
+}
+
+type postalAddress struct {
+	Street     string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+type orderModel struct {
+	Shipping *postalAddress `form_widget:"address"`
+}
+
+func TestAddressField(t *testing.T) {
+	m := &orderModel{Shipping: &postalAddress{Country: `DE`}}
+	form := forms.New()
+	form.Theme = common.BASE
+	form.ParseModel(m)
+	fs := form.FieldSet(`Shipping`)
+	names := func() []string {
+		var r []string
+		for _, f := range fs.Fields() {
+			r = append(r, f.OriginalName())
+		}
+		return r
+	}
+	// 德国的地址格式中没有的地区也生成（由脚本隐藏），选择其它国家后可以填写
+	assert.Equal(t, []string{`Shipping.Street`, `Shipping.PostalCode`, `Shipping.City`, `Shipping.Country`, `Shipping.Region`}, names())
+	html := fs.String()
+	assert.Contains(t, html, `class="address"`)
+	assert.Contains(t, html, `<option value="DE" selected="selected">Germany</option>`)
+	assert.Contains(t, html, `<input type="text" name="Shipping.Region">`)
+	assert.Contains(t, html, `{"name":"region","label":"State","required":true}`)
+	assert.Contains(t, html, `"region":"Shipping.Region"`)
+
+	values := url.Values{
+		`Shipping.Street`:     {`Unter den Linden 1`},
+		`Shipping.City`:       {``},
+		`Shipping.PostalCode`: {`10117`},
+		`Shipping.Country`:    {`DE`},
+	}
+	err := form.Bind(values, m)
+	assert.ErrorIs(t, err, forms.ErrRequired)
+	assert.Equal(t, `10117`, m.Shipping.PostalCode)
+	assert.Equal(t, `Can not be empty`, form.Validate().ErrorMap()[`Shipping.City`].Message)
+
+	values.Set(`Shipping.Country`, `US`)
+	values.Set(`Shipping.City`, `Berlin`)
+	assert.ErrorIs(t, form.Bind(values, m), forms.ErrRequired)
+	assert.NotNil(t, form.Validate().ErrorMap()[`Shipping.Region`])
+	form.Populate(values)
+	assert.Equal(t, []string{`Shipping.Street`, `Shipping.City`, `Shipping.Region`, `Shipping.PostalCode`, `Shipping.Country`}, names())
+	assert.Equal(t, `State`, fs.Field(`Shipping.Region`).(*fields.Field).Label)
+
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{Type: common.ADDRESS, Name: `shipping`, Value: `CN`})
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	assert.Empty(t, cfg.Elements[0].Elements)
+	fs = form.FieldSet(`shipping`)
+	assert.Equal(t, []string{`shipping.country`, `shipping.region`, `shipping.city`, `shipping.street`, `shipping.postalCode`}, names())
+	m = &orderModel{}
+	assert.NoError(t, form.Bind(url.Values{
+		`shipping.country`: {`CN`},
+		`shipping.region`:  {`Zhejiang`},
+		`shipping.city`:    {`Hangzhou`},
+		`shipping.street`:  {`1 Wensan Road`},
+	}, m))
+	assert.Equal(t, `Hangzhou`, m.Shipping.City)
+	assert.Equal(t, `CN`, m.Shipping.Country)
+}
+
+type contactPerson struct {
+	Name  string
+	Email string
+}
+
+type subformModel struct {
+	Contact   *contactPerson
+	Emergency map[string]string
+}
+
+func TestSubform(t *testing.T) {
+	person := forms.NewConfig()
+	person.AddElement(
+		&config.Element{Type: common.TEXT, Name: `name`, Valid: `required`},
+		&config.Element{Type: common.EMAIL, Name: `email`},
+	)
+	forms.RegisterSubform(`person`, person)
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(
+		&config.Element{Type: common.SUBFORM, Name: `contact`, Config: `person`},
+		&config.Element{Type: common.SUBFORM, Name: `emergency`, Config: `person`, Naming: config.NamingBrackets},
+		&config.Element{Type: common.SUBFORM, Name: `missing`, Config: `unknown`},
+	)
+	m := &subformModel{Contact: &contactPerson{Name: `Ann`}}
+	form := forms.NewWithConfig(cfg, m)
+	// 无法展开的 subform 在创建表单时就记录错误，生成字段时不再重复
+	assert.ErrorIs(t, form.BuildError(), forms.ErrSubformNotFound)
+	err := form.ParseFromConfigE()
+	assert.ErrorIs(t, err, forms.ErrSubformNotFound)
+	assert.Len(t, form.BuildErrors(), 1)
+	assert.Equal(t, `missing`, form.BuildErrors()[0].Field)
+	assert.Equal(t, `Ann`, form.FieldSet(`contact`).Field(`contact.name`).(*fields.Field).Value)
+	assert.Contains(t, form.FieldSet(`emergency`).String(), `name="emergency[email]"`)
+	assert.Len(t, person.Elements, 2)
+	assert.Equal(t, `name`, person.Elements[0].Name)
+
+	assert.NoError(t, form.Bind(url.Values{
+		`contact.name`:     {`Bob`},
+		`emergency[name]`:  {`Eve`},
+		`emergency[email]`: {`eve@example.com`},
+	}, m))
+	assert.Equal(t, `Bob`, m.Contact.Name)
+	assert.Equal(t, map[string]string{`name`: `Eve`, `email`: `eve@example.com`}, m.Emergency)
+
+	m.Emergency[`name`] = ``
+	form.ValidFromConfig(m)
+	assert.NotNil(t, form.Validate().ErrorMap()[`emergency[name]`])
+	assert.Nil(t, form.Validate().ErrorMap()[`contact.name`])
+
+	bad := forms.NewConfig()
+	bad.AddElement(&config.Element{Type: common.SUBFORM, Name: `contact`, Config: `person`, Naming: `camel`})
+	assert.ErrorContains(t, forms.NewWithConfig(bad).BuildError(), `invalid subform naming: camel`)
+
+	// 共享的配置不会被展开（go test -race）
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			forms.NewWithConfig(cfg, &subformModel{})
+		}()
+	}
+	wg.Wait()
+	assert.Empty(t, cfg.Elements[0].Elements)
+}
+
+func TestRenderReadonly(t *testing.T) {
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.Mode = config.ModeReadonly
+	cfg.AddElement(
+		&config.Element{Type: common.SELECT, Name: `country`, Value: `cn`, Choices: []*config.Choice{
+			{Option: []string{`us`, `United States`}},
+			{Option: []string{`cn`, `China`}},
+		}},
+		&config.Element{Type: common.DATE, Name: `birthday`, Value: `2024-03-05`, Format: `02/01/2006`},
+		&config.Element{Type: common.SWITCH, Name: `active`, Value: `1`},
+		&config.Element{Type: common.PASSWORD, Name: `secret`, Value: `hunter2`},
+		&config.Element{Type: common.HIDDEN, Name: `token`, Value: `abc`},
+		&config.Element{Type: `langset`, Name: `i18n`, Languages: []*config.Language{
+			{ID: `en`, Label: `English`},
+			{ID: `zh`, Label: `Chinese`},
+		}, Elements: []*config.Element{
+			{Type: common.TEXT, Name: `title`, Label: `Title`},
+		}},
+	)
+	form := forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	html := form.String()
+	assert.Contains(t, html, `>China</div>`)
+	assert.NotContains(t, html, `United States`)
+	assert.Contains(t, html, `>05/03/2024</div>`)
+	assert.Contains(t, html, `>Yes</div>`)
+	assert.Contains(t, html, fields.DisplayPasswordMask)
+	assert.NotContains(t, html, `hunter2`)
+	assert.NotContains(t, html, `token`)
+	assert.Contains(t, html, `<div role="tabpanel" class="tab-pane" id="display_i18n_zh_Forms">`)
+	assert.Equal(t, html, string(form.RenderReadonly()))
+
+	form.Theme = common.BASE
+	html = form.String()
+	assert.Contains(t, html, `>China</div>`)
+	assert.Contains(t, html, `<div role="tabpanel" id="display_i18n_zh_Forms" aria-labelledby="display_i18n_zh_Forms_tab" data-lang="zh" hidden>`)
+}
+
+type productModel struct {
+	Title  string
+	Price  int
+	Role   string
+	Status string
+}
+
+func TestAuthorizer(t *testing.T) {
+	cfg := forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(
+		&config.Element{Type: common.TEXT, Name: `title`},
+		&config.Element{Type: common.NUMBER, Name: `price`, Perm: `price.edit`},
+		&config.Element{Type: common.TEXT, Name: `role`, Data: map[string]interface{}{`perm`: `admin`}},
+		&config.Element{Type: `fieldset`, Name: `meta`, Perm: `meta.edit`, Elements: []*config.Element{
+			{Type: common.SELECT, Name: `status`, Choices: []*config.Choice{{Option: []string{`draft`, `Draft`}}}},
+		}},
+	)
+	editor := forms.PermAuthorizer(func(perm string) bool { return false }, forms.AccessReadonly)
+	m := &productModel{Title: `Hello`, Price: 10, Role: `author`, Status: `draft`}
+	form := forms.NewWithConfig(cfg, m)
+	form.SetAuthorizer(func(ele *config.Element) forms.Access {
+		if ele.Permission() == `admin` {
+			return forms.AccessHidden
+		}
+		return editor(ele)
+	})
+	form.ParseFromConfig()
+	html := form.String()
+	assert.NotContains(t, html, `name="role"`)
+	assert.Contains(t, form.Field(`price`).String(), ` readonly`)
+	assert.Contains(t, form.FieldSet(`meta`).String(), ` disabled`)
+	assert.NotContains(t, form.Field(`title`).String(), ` readonly`)
+
+	values := url.Values{
+		`title`:  {`Changed`},
+		`price`:  {`0`},
+		`role`:   {`admin`},
+		`status`: {`published`},
+	}
+	assert.NoError(t, form.Bind(values, m))
+	assert.Equal(t, productModel{Title: `Changed`, Price: 10, Role: `author`, Status: `draft`}, *m)
+	filtered, err := form.Filter(values)
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{`title`: {`Changed`}}, filtered)
+
+	// langset 中的元素以各语言的名称提交
+	cfg = forms.NewConfig()
+	cfg.WithButtons = false
+	cfg.AddElement(&config.Element{Type: `langset`, Languages: []*config.Language{
+		{ID: `en`, Label: `English`, NameFormat: `~`},
+		{ID: `zh`, Label: `中文`, NameFormat: `~`},
+	}, Elements: []*config.Element{
+		{Type: common.TEXT, Name: `title`},
+		{Type: common.TEXT, Name: `secret`, Perm: `secret.edit`},
+	}})
+	lm := &struct{ Language map[string]map[string]string }{}
+	form = forms.NewWithConfig(cfg, lm)
+	form.SetAuthorizer(editor)
+	form.ParseFromConfig()
+	assert.NoError(t, form.Bind(url.Values{
+		`Language[en][title]`:  {`Hello`},
+		`Language[en][secret]`: {`x`},
+		`Language[zh][secret]`: {`y`},
+	}, lm))
+	assert.Equal(t, map[string]map[string]string{`en`: {`title`: `Hello`}}, lm.Language)
+
+	// 按结构体生成的表单使用 form_perm 标签
+	pm := &permModel{Title: `Hello`, Price: 10, Role: `author`, Meta: permMeta{Status: `draft`}}
+	form = forms.New()
+	form.SetAuthorizer(func(ele *config.Element) forms.Access {
+		if ele.Permission() == `admin` {
+			return forms.AccessHidden
+		}
+		return editor(ele)
+	})
+	form.ParseModel(pm)
+	assert.NotContains(t, form.String(), `name="Role"`)
+	assert.Contains(t, form.Field(`Price`).String(), ` readonly`)
+	assert.Contains(t, form.Field(`Meta.Status`).String(), ` readonly`)
+	assert.NotContains(t, form.Field(`Title`).String(), ` readonly`)
+	assert.NoError(t, form.Bind(url.Values{
+		`Title`:       {`Changed`},
+		`Price`:       {`0`},
+		`Role`:        {`admin`},
+		`Meta.Status`: {`published`},
+	}, pm))
+	assert.Equal(t, permModel{Title: `Changed`, Price: 10, Role: `author`, Meta: permMeta{Status: `draft`}}, *pm)
+}
+
+type permMeta struct {
+	Status string
+}
+
+type permModel struct {
+	Title string
+	Price int      `form_perm:"price.edit"`
+	Role  string   `form_perm:"admin"`
+	Meta  permMeta `form_perm:"meta.edit"`
+}
+
+type accountModel struct {
+	Name   string
+	Email  string `form_params:"readonly=readonly"`
+	Status string `form_widget:"static"`
+}
+
+func TestStrictBind(t *testing.T) {
+	m := &accountModel{Email: `a@example.com`, Status: `active`}
+	form := forms.New()
+	form.ParseModel(m)
+	values := url.Values{`Name`: {`Ann`}, `Email`: {`b@example.com`}, `Status`: {`banned`}}
+	assert.NoError(t, form.Bind(values, m))
+	assert.Equal(t, accountModel{Name: `Ann`, Email: `a@example.com`, Status: `active`}, *m)
+
+	form.SetStrictBind(true, `csrf`)
+	values.Set(`csrf`, `token`)
+	assert.NoError(t, form.Bind(values, m))
+	values.Set(`IsAdmin`, `1`)
+	values.Set(`Role`, `admin`)
+	err := form.Bind(values, m)
+	assert.ErrorIs(t, err, forms.ErrUnexpectedField)
+	assert.Equal(t, `unexpected field: IsAdmin, Role`, err.Error())
+
+	cfg := forms.NewConfig()
+	cfg.AddElement(
+		&config.Element{Type: common.TEXT, Name: `name`},
+		&config.Element{Type: common.TEXT, Name: `email`, Attributes: [][]string{{config.Disabled}}},
+	)
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	form.SetStrictBind(true)
+	data := map[string]string{}
+	assert.NoError(t, form.Bind(url.Values{`name`: {`Bob`}, `email`: {`x@example.com`}, `submit`: {``}}, &data))
+	assert.Equal(t, map[string]string{`name`: `Bob`}, data)
+	assert.ErrorIs(t, form.Bind(url.Values{`name`: {`Bob`}, `role`: {`admin`}}, &data), forms.ErrUnexpectedField)
+}
+
+type signupModel struct {
+	Name  string `form_filter:"trim;collapse"`
+	Email string `form_widget:"email" form_filter:"email" valid:"email"`
+}
+
+func TestFilters(t *testing.T) {
+	m := &signupModel{}
+	form := forms.New()
+	form.ParseModel(m)
+	assert.NoError(t, form.Bind(url.Values{`Name`: {"  Ann   Lee "}, `Email`: {` Ann@Example.COM `}}, m))
+	assert.Equal(t, signupModel{Name: `Ann Lee`, Email: `ann@example.com`}, *m)
+	assert.NoError(t, form.Valid())
+
+	cfg := forms.NewConfig()
+	cfg.AddElement(
+		&config.Element{Type: common.EMAIL, Name: `email`, Valid: `email`, Filters: []string{`email`}},
+		&config.Element{Type: common.TEXT, Name: `code`, Filters: []string{`nope`}},
+	)
+	form = forms.NewWithConfig(cfg)
+	err := form.ParseFromConfigE()
+	assert.ErrorIs(t, err, fields.ErrUnknownFilter)
+	filtered, verr := form.Filter(url.Values{`email`: {` Ann@Example.COM `}})
+	assert.Nil(t, verr)
+	assert.Equal(t, url.Values{`email`: {`ann@example.com`}}, filtered)
+	data := map[string]string{}
+	assert.ErrorIs(t, form.Bind(url.Values{`code`: {`x`}}, &data), fields.ErrUnknownFilter)
+
+	// 与 Bind 相同，丢弃静态和只读元素提交的值
+	cfg = forms.NewConfig()
+	cfg.AddElement(
+		&config.Element{Type: common.TEXT, Name: `name`},
+		&config.Element{Type: common.TEXT, Name: `ro`, Attributes: [][]string{{`readonly`}}},
+		&config.Element{Type: common.STATIC, Name: `st`},
+	)
+	form = forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	values := url.Values{`name`: {`a`}, `ro`: {`y`}, `st`: {`x`}}
+	filtered, verr = form.Filter(values)
+	assert.Nil(t, verr)
+	assert.Equal(t, url.Values{`name`: {`a`}}, filtered)
+	bound := map[string]string{}
+	assert.NoError(t, form.Bind(values, &bound))
+	assert.Equal(t, map[string]string{`name`: `a`}, bound)
+}
+
+type usernameModel struct {
+	Username string `form_filter:"trim;lower" form_remote:"username"`
+}
+
+func TestRemoteValidator(t *testing.T) {
+	forms.RegisterRemoteValidator(`username`, func(ctx context.Context, value string, values url.Values) error {
+		if value == `taken` {
+			return errors.New(`Username is already taken`)
+		}
+		return nil
+	})
+	cfg := forms.NewConfig()
+	cfg.RemoteURL = `/check`
+	cfg.AddElement(
+		&config.Element{Type: common.TEXT, Name: `username`, Filters: []string{`trim`}, Remote: `username`},
+		&config.Element{Type: common.TEXT, Name: `nickname`},
+	)
+	form := forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	assert.Contains(t, string(form.Render()), `data-remote="/check"`)
+
+	handler := &forms.RemoteHandler{Config: cfg}
+	check := func(values url.Values) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, `/check?`+values.Encode(), nil))
+		return rec.Code, rec.Body.String()
+	}
+	code, body := check(url.Values{forms.RemoteFieldParam: {`username`}, `username`: {`ann`}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"valid":true,"field":"username"}`, body)
+	code, body = check(url.Values{forms.RemoteFieldParam: {`username`}, `username`: {` taken `}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"valid":false,"field":"username","message":"Username is already taken"}`, body)
+	code, _ = check(url.Values{forms.RemoteFieldParam: {`nickname`}, `nickname`: {`x`}})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	_, verr := form.Filter(url.Values{`username`: {`taken`}})
+	assert.NotNil(t, verr)
+	data := map[string]string{}
+	form = forms.NewWithConfig(cfg)
+	assert.Error(t, form.Bind(url.Values{`username`: {`taken`}}, &data))
+
+	m := &usernameModel{}
+	form = forms.New()
+	form.SetRemoteURL(`/check`)
+	form.ParseModel(m)
+	assert.NotContains(t, form.Field(`Username`).String(), `data-remote`)
+	assert.Error(t, form.Bind(url.Values{`Username`: {` Taken `}}, m))
+	assert.NoError(t, form.Bind(url.Values{`Username`: {` Ann `}}, m))
+	assert.Equal(t, `ann`, m.Username)
+
+	// langset 中的元素以各语言的名称验证
+	cfg = forms.NewConfig()
+	cfg.AddElement(&config.Element{Type: `langset`, Languages: []*config.Language{{ID: `en`, NameFormat: `~`}}, Elements: []*config.Element{
+		{Type: common.TEXT, Name: `username`, Remote: `username`},
+	}})
+	handler = &forms.RemoteHandler{Config: cfg}
+	_, body = check(url.Values{forms.RemoteFieldParam: {`Language[en][username]`}, `Language[en][username]`: {`taken`}})
+	assert.JSONEq(t, `{"valid":false,"field":"Language[en][username]","message":"Username is already taken"}`, body)
+}
+
+func TestClientValidate(t *testing.T) {
+	rules, err := forms.ParseClientRules(`Required;minSize(3);range(1,10);match(/(?i)^[a-z]+$/);AlphaDash`, func(s string) string { return `T:` + s })
+	assert.NoError(t, err)
+	assert.Equal(t, []*forms.ClientRule{
+		{Rule: `match`, Pattern: `^[a-z]+$`, Flags: `i`, Message: `T:Must match (?i)^[a-z]+$`},
+		{Rule: `required`, Message: `T:Can not be empty`},
+		{Rule: `minSize`, Args: []interface{}{3}, Message: `T:Minimum size is 3`},
+		{Rule: `range`, Args: []interface{}{float64(1), float64(10)}, Message: `T:Range is 1 to 10`},
+		{Rule: `alphaDash`, Pattern: validation.DefaultRule.AlphaDash, Negate: true, Message: `T:Must be valid alpha or numeric or dash(-_) characters`},
+	}, rules)
+	_, err = forms.ParseClientRules(`length(a)`, nil)
+	assert.Error(t, err)
+
+	cfg := forms.NewConfig()
+	cfg.ClientValidate = true
+	cfg.AddElement(
+		&config.Element{Type: common.TEXT, Name: `code`, Valid: `required;length(4)`, Filters: []string{`trim`}},
+		&config.Element{Type: common.TEXT, Name: `slug`, Valid: `required`, Filters: []string{`slugify`}},
+		&config.Element{Type: common.TEXT, Name: `note`, Valid: `maxSize(10)`, Attributes: [][]string{{`readonly`}}},
+	)
+	form := forms.NewWithConfig(cfg)
+	form.ParseFromConfig()
+	manifest := form.ValidationManifest()
+	assert.Len(t, manifest, 1)
+	assert.Equal(t, []string{`trim`}, manifest[`code`].Filters)
+	assert.Len(t, manifest[`code`].Rules, 2)
+	html := string(form.Render())
+	assert.Contains(t, html, `<script type="application/json" data-validate-rules>{"code":{"filters":["trim"],"rules":[{"rule":"required","message":"Can not be empty"},{"rule":"length","args":[4],"message":"Required length is 4"}]}}</script>`)
+	assert.Contains(t, html, `remoteField = "_field"`)
+}
@@ -11,7 +11,10 @@ require (
 	github.com/webx-top/com v1.4.1
 	github.com/webx-top/tagfast v0.0.1
 	github.com/webx-top/validation v0.0.3
+	golang.org/x/net v0.48.0
 	golang.org/x/sync v0.19.0
+	golang.org/x/text v0.32.0
+	golang.org/x/tools v0.40.0
 )
 
 require (
@@ -26,7 +29,7 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
+	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
-	golang.org/x/text v0.32.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -148,55 +148,76 @@ func (form *Form) ValidFromConfig(model ...interface{}) *Form {
 	return form
 }
 
-// Filter 过滤客户端提交的数据
+// Filter 过滤客户端提交的数据（不可编辑元素提交的值会被丢弃，见 SetAuthorizer）
 func (form *Form) Filter(values url.Values) (url.Values, *validation.ValidationError) {
 	form.Validate()
-	r := url.Values{}
+	return form.filterElements(values, url.Values{}, form.config.Elements)
+}
+
+// filterElements 过滤元素（包括 langset、fieldset、address 和 subform 的子元素）
+func (form *Form) filterElements(input url.Values, output url.Values, elements []*config.Element) (url.Values, *validation.ValidationError) {
 	var err *validation.ValidationError
-	for _, ele := range form.config.Elements {
-		switch ele.Type {
-		case `langset`, `fieldset`:
-			for _, e := range ele.Elements {
-				r, err = form.FilterByElement(values, r, e)
-				if err != nil {
-					return r, err
-				}
-			}
-		default:
-			r, err = form.FilterByElement(values, r, ele)
-			if err != nil {
-				return r, err
+	for _, ele := range elements {
+		if !form.authorize(ele).Writable() { // 丢弃不可编辑元素（包括子元素）提交的值
+			continue
+		}
+		if ele.Type == `langset` || ele.IsFieldSet() {
+			output, err = form.filterElements(input, output, ele.Elements)
+			if err == nil && ele.Type == common.ADDRESS && form.validAddress(input, addressPartNames(ele)) != nil {
+				err = form.Error()
 			}
+		} else {
+			output, err = form.FilterByElement(input, output, ele)
+		}
+		if err != nil {
+			return output, err
 		}
 	}
-	return r, err
+	return output, err
 }
 
-// FilterByElement 过滤单个元素
+// FilterByElement 过滤单个元素：依次执行元素的过滤器（filters）、去掉输入掩码中的字符、验证（包括远程验证器）并过滤 HTML。
+// 与 Bind 相同，static、按钮、只读和禁用的元素提交的值会被丢弃
 func (form *Form) FilterByElement(input url.Values, output url.Values, ele *config.Element) (url.Values, *validation.ValidationError) {
-	if len(ele.Valid) == 0 {
-		if vals, ok := input[ele.Name]; ok {
-			output[ele.Name] = vals
-		}
-	} else {
-		if vals, ok := input[ele.Name]; ok {
-			for _, val := range vals {
-				if !form.valid.ValidField(ele.Name, val, ele.Valid) {
-					return output, form.Error()
-				}
+	if !ele.IsSubmittable() {
+		return output, form.Error()
+	}
+	vals, ok := input[ele.Name]
+	if !ok {
+		return output, form.Error()
+	}
+	vals, err := fields.FilterValues(ele.Filters, vals)
+	if err != nil {
+		form.Validate().SetError(ele.Name, err.Error())
+		return output, form.Error()
+	}
+	vals = fields.UnmaskValues(ele.Mask, vals)
+	if len(ele.Valid) > 0 {
+		for _, val := range vals {
+			if !form.valid.ValidField(ele.Name, val, ele.Valid) {
+				return output, form.Error()
 			}
-			output[ele.Name] = vals
 		}
 	}
+	if len(ele.Lookup) > 0 && form.validLookup(ele.Name, ele.Lookup, vals) != nil {
+		return output, form.Error()
+	}
+	if len(ele.Remote) > 0 && form.validRemote(ele.Name, ele.Remote, vals, input) != nil {
+		return output, form.Error()
+	}
+	output[ele.Name] = fields.SanitizeValues(ele.Type, ele.Policy, vals)
 	return output, form.Error()
 }
 
 func (form *Form) ValidElements(elements []*config.Element, t reflect.Type, v reflect.Value) {
 	for _, ele := range elements {
+		if !form.authorize(ele).Writable() {
+			continue
+		}
 		switch ele.Type {
 		case `langset`:
 			form.ValidElements(ele.Elements, t, v)
-		case `fieldset`:
+		case `fieldset`, common.ADDRESS, common.SUBFORM:
 			form.ValidElements(ele.Elements, t, v)
 		default:
 			if !form.IsIgnored(ele.Name) {
@@ -278,6 +299,10 @@ func (form *Form) ParseModelElements(model interface{}, es ElementSetter,
 	elements []*config.Element, langs []*config.Language,
 	t reflect.Type, v reflect.Value, lang string) {
 	for _, ele := range elements {
+		access := form.authorize(ele)
+		if access == AccessHidden {
+			continue
+		}
 		switch ele.Type {
 		case `langset`:
 			if ele.Languages == nil {
@@ -302,9 +327,21 @@ func (form *Form) ParseModelElements(model interface{}, es ElementSetter,
 					f.AddTag(v[0])
 				}
 			}
-			es.Elements(f)
-		case `fieldset`:
+			es.Elements(restrictElement(f, access))
+		case `fieldset`, common.SUBFORM:
 			f := form.NewFieldSet(ele.Name, form.labelFn(ele.Label))
+			if ele.Type == common.SUBFORM {
+				if len(ele.Elements) == 0 && !form.subformFailed(ele.Name) {
+					// 表单配置中的 subform 已在 Init 中展开，这里展开其它来源的元素。
+					// 不修改（可能被共享的）配置，展开到副本中
+					ele = ele.Clone()
+					for _, err := range expandSubforms([]*config.Element{ele}) {
+						form.addBuildError(err.Field, err.Err)
+					}
+					expandAddressElements(ele.Elements)
+				}
+				f.AddClass(common.SUBFORM)
+			}
 			if len(ele.Template) > 0 {
 				f.SetTemplate(ele.Template)
 			}
@@ -318,12 +355,16 @@ func (form *Form) ParseModelElements(model interface{}, es ElementSetter,
 			f.SetFieldCols(ele.FieldCols)
 			f.SetLang(lang)
 			f.SetHelpText(form.labelFn(ele.HelpText))
-			es.Elements(f)
+			es.Elements(restrictElement(f, access))
+		case common.ADDRESS:
+			f := form.parseAddressElement(model, ele, t, v)
+			f.SetLang(lang)
+			es.Elements(restrictElement(f, access))
 		default:
 			f := form.parseElement(model, ele, t, v)
 			if f != nil {
 				f.SetLang(lang)
-				es.Elements(f)
+				es.Elements(restrictElement(f, access))
 			}
 		}
 	}
@@ -347,6 +388,25 @@ func (form *Form) cleanName(name string) string {
 	return name
 }
 
+// elementChoices 返回元素的选项，如果指定了选项提供者则从提供者获取
+func (form *Form) elementChoices(ele *config.Element) []*config.Choice {
+	if len(ele.Provider) == 0 {
+		return ele.Choices
+	}
+	choices, err := fields.ProvideChoices(form.Context(), ele.Provider, form.Lang())
+	if err != nil {
+		form.addBuildError(ele.Name, err)
+		return nil
+	}
+	valid := make([]*config.Choice, 0, len(choices))
+	for _, v := range choices {
+		if len(v.Option) >= 2 {
+			valid = append(valid, v)
+		}
+	}
+	return valid
+}
+
 func (form *Form) parseElement(model interface{}, ele *config.Element, typ reflect.Type, val reflect.Value) (f *fields.Field) {
 	var sv string
 	value := val
@@ -402,80 +462,27 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 	isStruct := typ != nil && typ.Kind() == reflect.Struct
 	structFieldName := com.Title(form.cleanName(ele.GetFieldName()))
 	switch ele.Type {
-	case common.DATE:
-		dateFormat := fields.DATE_FORMAT
-		if len(ele.Format) > 0 {
-			dateFormat = ele.Format
-		} else if isStruct {
+	case common.DATE, common.DATETIME, common.DATETIME_LOCAL, common.TIME, common.MONTH, common.WEEK:
+		// 浏览器原生的日期时间类型总是使用固定的格式，只有 datetime 可以自定义格式（原生类型的格式只用于只读模式下的显示）
+		dateFormat := fields.TimeFormat(ele.Type, ele.Attr(`step`))
+		displayFormat := ele.Format
+		if len(displayFormat) == 0 && isStruct {
 			if structField, ok := typ.FieldByName(structFieldName); ok {
-				if format := tagfast.Value(typ, structField, `form_format`); len(format) > 0 {
-					dateFormat = format
-				}
+				displayFormat = tagfast.Value(typ, structField, `form_format`)
 			}
 		}
-		f = fields.TextField(ele.Name, ele.Type)
-		if v, isEmpty := fields.ConvertTime(value.Interface()); !v.IsZero() {
-			f.SetValue(v.Format(dateFormat))
-		} else if isEmpty {
-			f.SetValue(``)
-		} else {
-			f.SetValue(ele.Value)
-		}
-
-	case common.DATETIME:
-		dateFormat := fields.DATETIME_FORMAT
-		if len(ele.Format) > 0 {
-			dateFormat = ele.Format
-		} else if isStruct {
-			if structField, ok := typ.FieldByName(structFieldName); ok {
-				if format := tagfast.Value(typ, structField, `form_format`); len(format) > 0 {
-					dateFormat = format
-				}
-			}
+		if !fields.IsNativeTimeType(ele.Type) && len(displayFormat) > 0 {
+			dateFormat = displayFormat
 		}
 		f = fields.TextField(ele.Name, ele.Type)
-		if v, isEmpty := fields.ConvertTime(value.Interface()); !v.IsZero() {
-			f.SetValue(v.Format(dateFormat))
-		} else if isEmpty {
-			f.SetValue(``)
-		} else {
-			f.SetValue(ele.Value)
-		}
-
-	case common.DATETIME_LOCAL:
-		dateFormat := fields.DATETIME_FORMAT
-		if len(ele.Format) > 0 {
-			dateFormat = ele.Format
-		} else if isStruct {
-			if structField, ok := typ.FieldByName(structFieldName); ok {
-				if format := tagfast.Value(typ, structField, `form_format`); len(format) > 0 {
-					dateFormat = format
-				}
-			}
+		f.Format = dateFormat
+		if len(displayFormat) > 0 {
+			f.SetDisplayFormat(displayFormat)
 		}
-		f = fields.TextField(ele.Name, ele.Type)
-		if v, isEmpty := fields.ConvertTime(value.Interface()); !v.IsZero() {
-			f.SetValue(v.Local().Format(dateFormat))
-		} else if isEmpty {
-			f.SetValue(``)
-		} else {
+		if !value.IsValid() { // 没有模型
 			f.SetValue(ele.Value)
-		}
-
-	case common.TIME:
-		dateFormat := fields.TIME_FORMAT
-		if len(ele.Format) > 0 {
-			dateFormat = ele.Format
-		} else if isStruct {
-			if structField, ok := typ.FieldByName(structFieldName); ok {
-				if format := tagfast.Value(typ, structField, `form_format`); len(format) > 0 {
-					dateFormat = format
-				}
-			}
-		}
-		f = fields.TextField(ele.Name, ele.Type)
-		if v, isEmpty := fields.ConvertTime(value.Interface()); !v.IsZero() {
-			f.SetValue(v.Format(dateFormat))
+		} else if v, isEmpty := fields.ConvertTime(value.Interface()); !v.IsZero() {
+			f.SetValue(fields.FormatTime(v, dateFormat, form.location))
 		} else if isEmpty {
 			f.SetValue(``)
 		} else {
@@ -504,7 +511,7 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 			}
 		}
 
-	case common.COLOR, common.EMAIL, common.FILE, common.HIDDEN, common.IMAGE, common.MONTH, common.SEARCH, common.URL, common.TEL, common.WEEK, common.NUMBER, common.PASSWORD:
+	case common.COLOR, common.EMAIL, common.FILE, common.HIDDEN, common.IMAGE, common.SEARCH, common.URL, common.TEL, common.NUMBER, common.PASSWORD:
 		f = fields.TextField(ele.Name, ele.Type)
 		if len(sv) == 0 {
 			f.SetValue(ele.Value)
@@ -512,10 +519,26 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 			f.SetValue(sv)
 		}
 
+	case common.MONEY, common.DECIMAL:
+		precision := ele.GetPrecision(fields.DefaultPrecision)
+		f = fields.FieldWithType(ele.Name, ele.Type)
+		f.SetPrecision(precision)
+		f.SetCurrency(ele.Currency)
+		f.SetLocale(form.Lang())
+		f.SetValue(ele.Value)
+		if len(sv) > 0 && value.IsValid() && value.CanInterface() {
+			// 模型中的整数为最小货币单位
+			if n, err := fields.DecimalToMinor(value.Interface(), precision); err == nil {
+				f.SetValue(fields.DecimalString(n, precision))
+			} else {
+				f.SetValue(sv)
+			}
+		}
+
 	case common.CHECKBOX, common.RADIO:
 		choices := []fields.InputChoice{}
 		hasSet := len(sv) > 0
-		for _, v := range ele.Choices {
+		for _, v := range form.elementChoices(ele) {
 			if v.Checked {
 				if hasSet && sv != v.Option[0] {
 					v.Checked = false
@@ -525,11 +548,7 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 					v.Checked = sv == v.Option[0]
 				}
 			}
-			ic := fields.InputChoice{
-				ID:      v.Option[0],
-				Val:     form.labelFn(v.Option[1]),
-				Checked: v.Checked,
-			}
+			ic := fields.ChoiceFromConfig(v, form.labelFn)
 			choices = append(choices, ic)
 		}
 		if ele.Type == common.CHECKBOX {
@@ -551,6 +570,43 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 			f.SetValue(sv)
 		}
 
+	case common.TAGS:
+		f = fields.TagsField(ele.Name, fields.SplitTags(ele.Value)...)
+		if len(sv) > 0 && value.IsValid() && value.CanInterface() {
+			if tags := fields.ValueTags(value.Interface()); len(tags) > 0 {
+				f.SetTagValues(tags...)
+			}
+		}
+		maxCount, maxLength, err := fields.ParseTagsLimit(ele.Attr(`max`), ele.Attr(`maxlength`))
+		if err != nil {
+			form.addBuildError(ele.Name, err)
+		}
+		f.SetTagsLimit(maxCount, maxLength)
+		f.SetChoicesFromConfig(form.elementChoices(ele), form.labelFn)
+
+	case common.SWITCH:
+		f = fields.SwitchField(ele.Name, fields.IsChecked(ele.Value), form.translateLabels(ele.Labels)...)
+		if len(sv) > 0 {
+			f.SetValue(sv)
+		}
+
+	case common.RATING:
+		max := fields.DefaultRatingMax
+		if v := ele.Attr(`max`); len(v) > 0 {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				max = n
+			} else {
+				form.addBuildError(ele.Name, fmt.Errorf(`invalid rating max: %s`, v))
+			}
+		}
+		f = fields.RatingField(ele.Name, max)
+		f.SetLabels(form.translateLabels(ele.Labels)...)
+		if len(sv) == 0 || sv == `0` {
+			f.SetValue(ele.Value)
+		} else {
+			f.SetValue(sv)
+		}
+
 	case common.BUTTON, common.RESET, common.SUBMIT, common.STATIC, common.TEXTAREA:
 		f = fields.FieldWithType(ele.Name, ele.Type)
 		if len(sv) == 0 {
@@ -559,13 +615,34 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 			f.SetText(sv)
 		}
 
+	case common.RICHTEXT, common.MARKDOWN:
+		f = fields.FieldWithType(ele.Name, ele.Type)
+		f.SetSanitizePolicy(ele.Policy)
+		if len(ele.Policy) > 0 {
+			if _, ok := fields.GetSanitizePolicy(ele.Policy); !ok {
+				form.addBuildError(ele.Name, fmt.Errorf(`sanitize policy not found: %s`, ele.Policy))
+			}
+		}
+		if len(sv) == 0 {
+			f.SetText(ele.Value)
+		} else {
+			f.SetText(sv)
+		}
+
+	case common.AUTOCOMPLETE:
+		ff := fields.AutocompleteField(ele.Name, ele.URL)
+		if len(sv) == 0 {
+			ff.SetValue(ele.Value)
+		} else {
+			ff.SetValue(sv)
+		}
+		form.applyLookup(ff, ele.Lookup)
+		f = ff
+
 	case common.SELECT:
-		choices := map[string][]fields.InputChoice{}
+		choices := fields.ChoiceGroups{}
 		hasSet := len(sv) > 0
-		for _, v := range ele.Choices {
-			if _, ok := choices[v.Group]; !ok {
-				choices[v.Group] = []fields.InputChoice{}
-			}
+		for _, v := range form.elementChoices(ele) {
 			if v.Checked {
 				if hasSet && sv != v.Option[0] {
 					v.Checked = false
@@ -575,12 +652,8 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 					v.Checked = sv == v.Option[0]
 				}
 			}
-			ic := fields.InputChoice{
-				ID:      v.Option[0],
-				Val:     form.labelFn(v.Option[1]),
-				Checked: v.Checked,
-			}
-			choices[v.Group] = append(choices[v.Group], ic)
+			ic := fields.ChoiceFromConfig(v, form.labelFn)
+			choices.Add(v.Group, ic)
 		}
 		f = fields.SelectField(ele.Name, choices)
 		if !hasSet {
@@ -592,6 +665,9 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 	default:
 		return nil
 	}
+	if fields.IsDatalistType(ele.Type) && (len(ele.Choices) > 0 || len(ele.Provider) > 0) {
+		f.SetChoicesFromConfig(form.elementChoices(ele), form.labelFn)
+	}
 	for _, v := range ele.Attributes {
 		switch len(v) {
 		case 2:
@@ -600,6 +676,15 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 			f.AddTag(v[0])
 		}
 	}
+	switch ele.Type {
+	case common.RATING:
+		// max 是星星的数量，不是单选框的属性
+		f.DeleteParam(`max`)
+	case common.TAGS:
+		// max 和 maxlength 是 tags 的数量和长度限制，不是输入框的属性
+		f.DeleteParam(`max`)
+		f.DeleteParam(`maxlength`)
+	}
 	f.SetHelpText(form.labelFn(ele.HelpText))
 	f.SetLabel(form.labelFn(ele.Label))
 	for _, labelClass := range ele.LabelClasses {
@@ -609,6 +694,14 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 	f.SetID(ele.ID)
 	if len(ele.Valid) > 0 {
 		form.validTagFn(ele.Valid, f)
+		f.SetValidRule(ele.Valid, ele.Filters)
+	}
+	if len(ele.Mask) > 0 && fields.IsMaskType(ele.Type) {
+		f.SetMask(ele.Mask)
+	}
+	form.addBuildError(ele.Name, fields.CheckFilters(ele.Filters))
+	if len(ele.Remote) > 0 {
+		f.SetRemote(form.RemoteURL())
 	}
 	for key, val := range ele.Data {
 		f.SetData(key, val)
@@ -619,6 +712,18 @@ func (form *Form) parseElement(model interface{}, ele *config.Element, typ refle
 	return f
 }
 
+// translateLabels 翻译元素的 labels
+func (form *Form) translateLabels(labels []string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	r := make([]string, len(labels))
+	for i, v := range labels {
+		r[i] = form.labelFn(v)
+	}
+	return r
+}
+
 func (form *Form) validElement(ele *config.Element, _ reflect.Type, val reflect.Value) bool {
 	if len(ele.Valid) == 0 {
 		return true
@@ -627,15 +732,32 @@ func (form *Form) validElement(ele *config.Element, _ reflect.Type, val reflect.
 	value := val
 	isValid := true
 	for _, field := range parts {
-		field = com.Title(field)
 		if value.Kind() == reflect.Pointer {
 			if value.IsNil() {
+				if !value.CanSet() {
+					isValid = false
+					break
+				}
 				value.Set(reflect.New(value.Type().Elem()))
 			}
 			value = value.Elem()
 		}
-		value = value.FieldByName(field)
-		if !value.IsValid() {
+		switch value.Kind() {
+		case reflect.Struct:
+			value = value.FieldByName(com.Title(field))
+		case reflect.Map: // subform 可以绑定到 map
+			if value.Type().Key().Kind() != reflect.String {
+				isValid = false
+				break
+			}
+			value = value.MapIndex(reflect.ValueOf(field).Convert(value.Type().Key()))
+			if value.Kind() == reflect.Interface {
+				value = value.Elem()
+			}
+		default:
+			value = reflect.Value{}
+		}
+		if !isValid || !value.IsValid() {
 			isValid = false
 			break
 		}
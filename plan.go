@@ -0,0 +1,306 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/com"
+)
+
+// StructWidgets 可以通过 form_widget 标签指定的控件
+var StructWidgets = fields.StructWidgets
+
+// fieldPlan 结构体字段预编译的标签信息
+type fieldPlan struct {
+	Index         int
+	Name          string
+	Type          reflect.Type
+	Widget        string              // form_widget
+	Options       map[string]struct{} // form_options
+	Label         string              // form_label（为空时使用字段名）
+	Params        url.Values          // form_params
+	ParamsErr     error               // form_params 解析错误
+	Valid         string              // valid
+	FieldsetLabel string              // form_fieldset: label;name or name
+	FieldsetName  string
+	Sort          string         // form_sort
+	Format        string         // form_format
+	Step          string         // form_step
+	Choices       bool           // 是否指定了 form_choices
+	Provider      string         // form_choices:"provider:<name>" 中的选项提供者名称
+	Lookup        string         // form_lookup（autocomplete 的选项查询函数名称）
+	Datalist      string         // form_datalist（文本类输入框的输入建议）
+	Decimal       *decimalFormat // money 和 decimal 控件的数值格式（form_precision、form_min 和 form_max）
+	Policy        string         // form_policy（richtext 和 markdown 控件的 HTML 过滤策略名称）
+	Tags          *tagsFormat    // tags 控件的限制（form_max、form_maxlength 和 form_choices）
+	Mask          string         // form_mask（输入掩码）
+	Filters       []string       // form_filter（验证和绑定前依次执行的过滤器）
+	Remote        string         // form_remote（远程验证器名称）
+	Perm          string         // form_perm（编辑此字段所需的权限，见 Form.SetAuthorizer）
+	ValueType     reflect.Type   // 解引用指针并展开 sql.Null* 后的类型
+	Nested        bool           // 是否为需要展开的嵌套结构体
+	Embedded      bool           // 是否为匿名嵌入的结构体（展开时字段名不加前缀）
+}
+
+// structField 结构体中可绑定字段的元数据
+type structField struct {
+	Name        string // 以“.”连接的字段路径，如 Profile.Name
+	Index       []int
+	Type        reflect.Type
+	Format      string
+	Split       bool   // 没有选项的切片字段，提交的单个值以“,”分隔
+	Lookup      string // 选项查询函数名称，绑定时验证提交的值是否存在
+	Decimal     *decimalFormat
+	Editor      string // richtext 或 markdown，绑定前过滤提交的 HTML
+	Policy      string
+	Switch      bool // 开关控件，未提交时绑定为关闭
+	Tags        *tagsFormat
+	Mask        string            // 输入掩码，绑定前去掉掩码中的字符
+	Filters     []string          // 绑定前依次执行的过滤器
+	Remote      string            // 远程验证器名称，绑定后验证提交的值
+	Address     string            // 所属地址组合字段的路径
+	AddressPart string            // 地址中的部分（见 fields.AddressParts），绑定后按照国家验证必填的部分
+	Readonly    bool              // 静态、只读或禁用（form_params 中的 readonly 或 disabled）的字段，不绑定提交的值
+	Elements    []*config.Element // 所属的嵌套结构体和字段本身对应的元素（由外到内），用于授权
+}
+
+// structPlan 结构体类型的预编译信息，每种类型只解析一次
+type structPlan struct {
+	Type   reflect.Type
+	Fields []*fieldPlan
+
+	bindOnce   sync.Once
+	bindFields []*structField
+}
+
+var structPlans sync.Map // map[reflect.Type]*structPlan
+
+func getStructPlan(t reflect.Type) *structPlan {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if v, ok := structPlans.Load(t); ok {
+		return v.(*structPlan)
+	}
+	v, _ := structPlans.LoadOrStore(t, compileStructPlan(t))
+	return v.(*structPlan)
+}
+
+func compileStructPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{Type: t}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			// 非导出的匿名结构体中的导出字段仍然可以访问
+			continue
+		}
+		fp := &fieldPlan{
+			Index:   i,
+			Name:    field.Name,
+			Type:    field.Type,
+			Options: map[string]struct{}{},
+		}
+		if tag, tagf := common.Tag(t, field, "form_options"); len(tag) > 0 {
+			var optionsArr []string
+			if tagf != nil {
+				cached := tagf.Parsed("form_options", func() interface{} {
+					return strings.Split(tag, ";")
+				})
+				optionsArr = cached.([]string)
+			}
+			for _, opt := range optionsArr {
+				if len(opt) > 0 {
+					fp.Options[opt] = struct{}{}
+				}
+			}
+			if _, ok := fp.Options["-"]; ok {
+				continue
+			}
+		}
+		fp.Widget = common.TagVal(t, i, "form_widget")
+		fp.Label = common.TagVal(t, i, "form_label")
+		if len(fp.Label) == 0 {
+			fp.Label = com.Title(field.Name)
+		}
+		if params := common.TagVal(t, i, "form_params"); len(params) > 0 {
+			if paramsMap, err := url.ParseQuery(params); err == nil {
+				fp.Params = paramsMap
+			} else {
+				fp.ParamsErr = fmt.Errorf(`invalid form_params %q: %w`, params, err)
+			}
+		}
+		fp.Valid = common.TagVal(t, i, "valid")
+		if fieldset := common.TagVal(t, i, "form_fieldset"); len(fieldset) > 0 {
+			fieldsets := strings.SplitN(fieldset, ";", 2)
+			switch len(fieldsets) {
+			case 1:
+				fp.FieldsetLabel = fieldsets[0]
+				fp.FieldsetName = fieldsets[0]
+			case 2:
+				fp.FieldsetLabel = fieldsets[0]
+				fp.FieldsetName = fieldsets[1]
+			}
+		}
+		fp.Sort = common.TagVal(t, i, "form_sort")
+		fp.Format = common.TagVal(t, i, "form_format")
+		fp.Step = common.TagVal(t, i, "form_step")
+		if choices := common.TagVal(t, i, "form_choices"); len(choices) > 0 {
+			fp.Choices = true
+			fp.Provider, _ = fields.ChoiceProviderName(choices)
+		}
+		fp.Lookup = common.TagVal(t, i, "form_lookup")
+		fp.Datalist = common.TagVal(t, i, "form_datalist")
+		fp.Policy = common.TagVal(t, i, "form_policy")
+		fp.Mask = common.TagVal(t, i, "form_mask")
+		fp.Filters = fields.ParseFilters(common.TagVal(t, i, "form_filter"))
+		fp.Remote = common.TagVal(t, i, "form_remote")
+		fp.Perm = common.TagVal(t, i, "form_perm")
+		if fields.IsDecimalType(fp.Widget) {
+			fp.Decimal = newDecimalFormat(common.TagVal(t, i, "form_precision"), common.TagVal(t, i, "form_min"), common.TagVal(t, i, "form_max"))
+		}
+		if fp.Widget == common.TAGS {
+			fp.Tags = newTagsFormat(common.TagVal(t, i, "form_max"), common.TagVal(t, i, "form_maxlength"), nil)
+			if choices := common.TagVal(t, i, "form_choices"); len(fp.Provider) > 0 {
+				fp.Tags.Provider = fp.Provider
+			} else if len(choices) > 0 {
+				items := strings.Split(choices, "|")
+				fp.Tags.Allowed = map[string]struct{}{}
+				for j := 0; j+1 < len(items); j += 2 {
+					fp.Tags.Allowed[items[j]] = struct{}{}
+				}
+			}
+		}
+		fp.ValueType = fields.IndirectType(field.Type)
+		if _, ok := StructWidgets[fp.Widget]; !ok || fp.Widget == common.ADDRESS {
+			// 地址组合字段按照嵌套结构体绑定
+			fp.Nested = isNestedStruct(field.Type)
+			fp.Embedded = fp.Nested && field.Anonymous
+		}
+		plan.Fields = append(plan.Fields, fp)
+	}
+	return plan
+}
+
+// BindFields 返回展开嵌套结构体后的全部可绑定字段
+func (p *structPlan) BindFields() []*structField {
+	p.bindOnce.Do(func() {
+		p.bindFields = p.flatten(``, nil, []reflect.Type{p.Type}, nil)
+	})
+	return p.bindFields
+}
+
+func (p *structPlan) flatten(baseName string, baseIndex []int, parents []reflect.Type, elements []*config.Element) []*structField {
+	var list []*structField
+	for _, fp := range p.Fields {
+		name := fp.Name
+		if len(baseName) > 0 {
+			name = baseName + `.` + name
+		}
+		eles := append(slices.Clip(elements), fp.element(name))
+		index := make([]int, len(baseIndex)+1)
+		copy(index, baseIndex)
+		index[len(baseIndex)] = fp.Index
+		if fp.Nested {
+			ft := fp.ValueType
+			if isParentType(parents, ft) { // 避免自引用类型无限递归
+				continue
+			}
+			if fp.Embedded {
+				name = baseName
+			}
+			nested := getStructPlan(ft).flatten(name, index, append(parents, ft), eles)
+			if fp.Widget == common.ADDRESS {
+				for _, sf := range nested {
+					if part := strings.TrimPrefix(sf.Name, name+`.`); fields.IsAddressPart(com.LowerCaseFirst(part)) {
+						sf.Address = name
+						sf.AddressPart = com.LowerCaseFirst(part)
+					}
+				}
+			}
+			list = append(list, nested...)
+			continue
+		}
+		format := fp.Format
+		if fields.IsNativeTimeType(fp.Widget) {
+			// 浏览器以固定的格式提交
+			format = fields.TimeFormat(fp.Widget, fp.Step)
+		}
+		sf := &structField{
+			Name:     name,
+			Index:    index,
+			Type:     fp.Type,
+			Format:   format,
+			Split:    fields.IsMultiValue(fp.Type) && !fp.Choices,
+			Lookup:   fp.Lookup,
+			Decimal:  fp.Decimal,
+			Mask:     fp.Mask,
+			Filters:  fp.Filters,
+			Remote:   fp.Remote,
+			Elements: eles,
+		}
+		sf.Readonly = fp.Widget == common.STATIC || fp.Params.Has(`readonly`) || fp.Params.Has(`disabled`)
+		if fields.IsEditorType(fp.Widget) {
+			sf.Editor = fp.Widget
+			sf.Policy = fp.Policy
+		}
+		sf.Switch = fp.Widget == common.SWITCH
+		if fp.Tags != nil {
+			sf.Tags = fp.Tags
+			sf.Split = false
+		}
+		list = append(list, sf)
+	}
+	return list
+}
+
+// element 返回授权函数（见 Form.SetAuthorizer）使用的元素：类型为 form_widget，名称为字段路径（如 Profile.Name），
+// 权限为 form_perm
+func (fp *fieldPlan) element(name string) *config.Element {
+	return &config.Element{Type: fp.Widget, Name: name, Label: fp.Label, Perm: fp.Perm}
+}
+
+// isNestedStruct 结构体（或其指针）需要展开为多个字段，但时间、sql.Null* 以及可自行编解码的类型除外
+func isNestedStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	if _, ok := fields.SQLNullType(t); ok {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(textUnmarshalerType) && !pt.Implements(valuerType)
+}
+
+func isParentType(parents []reflect.Type, t reflect.Type) bool {
+	for _, p := range parents {
+		if p == t {
+			return true
+		}
+	}
+	return false
+}
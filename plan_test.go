@@ -0,0 +1,156 @@
+package forms
+
+import (
+	"database/sql"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+type planAddress struct {
+	City   string `form_label:"City" form_params:"placeholder=City"`
+	Street string `form_fieldset:"Address;address"`
+}
+
+type planNode struct {
+	Name string
+	Next *planNode
+}
+
+type planModel struct {
+	Name     string    `form_label:"Name" valid:"required;maxSize(30)" form_params:"placeholder=Your+name&data-x=1"`
+	Password string    `form_widget:"password"`
+	Age      int       `form_min:"0" form_max:"150" form_sort:"1"`
+	Active   bool      `form_options:"checked"`
+	Birthday time.Time `form_widget:"date"`
+	Skip     string    `form_options:"-"`
+	Address  *planAddress
+	Node     planNode
+	hidden   string
+}
+
+func TestStructPlan(t *testing.T) {
+	plan := getStructPlan(reflect.TypeOf(&planModel{}))
+	assert.Same(t, plan, getStructPlan(reflect.TypeOf(planModel{})))
+	names := make([]string, 0, len(plan.Fields))
+	for _, fp := range plan.Fields {
+		names = append(names, fp.Name)
+	}
+	assert.Equal(t, []string{`Name`, `Password`, `Age`, `Active`, `Birthday`, `Address`, `Node`}, names)
+	assert.Equal(t, `Your name`, plan.Fields[0].Params.Get(`placeholder`))
+	assert.True(t, plan.Fields[5].Nested)
+
+	bindNames := []string{}
+	for _, sf := range plan.BindFields() {
+		bindNames = append(bindNames, sf.Name)
+	}
+	assert.Equal(t, []string{`Name`, `Password`, `Age`, `Active`, `Birthday`, `Address.City`, `Address.Street`, `Node.Name`}, bindNames)
+
+	form := New()
+	form.ParseModel(&planModel{Name: `webx`})
+	assert.Equal(t, `webx`, form.Field(`Name`).Data()[`value`])
+	assert.Equal(t, `Your name`, form.Field(`Name`).Data()[`params`].(common.HTMLAttributes).Get(`placeholder`))
+	assert.NotNil(t, form.FieldSet(`address`).Field(`Address.Street`))
+}
+
+type planBase struct {
+	ID      uint
+	Created sql.NullTime
+}
+
+type planTypes struct {
+	planBase
+	Tags     []string `form_choices:"|a|A||b|B||c|C"`
+	Scores   []int
+	Meta     map[string]string
+	Nick     *string
+	Level    uint8
+	Weight   int32
+	Remark   sql.NullString
+	Quantity sql.NullInt64
+}
+
+func TestStructTypes(t *testing.T) {
+	nick := `webx`
+	m := &planTypes{
+		planBase: planBase{ID: 5},
+		Tags:     []string{`a`, `c`},
+		Scores:   []int{1, 2},
+		Meta:     map[string]string{`b`: `2`, `a`: `1`},
+		Nick:     &nick,
+		Remark:   sql.NullString{String: `ok`, Valid: true},
+	}
+	form := New()
+	form.ParseModel(m)
+	assert.Equal(t, `5`, form.Field(`ID`).Data()[`value`])
+	assert.Equal(t, `0`, form.Field(`ID`).Data()[`params`].(common.HTMLAttributes).Get(`min`))
+	assert.Equal(t, `Tags[]`, form.Field(`Tags`).Data()[`name`])
+	choices := form.Field(`Tags`).Data()[`choices`].(fields.ChoiceGroups).Group(``).Choices
+	assert.Equal(t, []bool{true, false, true}, []bool{choices[0].Checked, choices[1].Checked, choices[2].Checked})
+	assert.Equal(t, `1,2`, form.Field(`Scores`).Data()[`value`])
+	assert.Equal(t, `webx`, form.Field(`Nick`).Data()[`value`])
+	assert.Equal(t, common.NUMBER, form.Field(`Weight`).ElementType())
+	assert.Equal(t, `ok`, form.Field(`Remark`).Data()[`value`])
+	assert.Equal(t, ``, form.Field(`Quantity`).Data()[`value`])
+	assert.Len(t, form.FieldSet(`Meta`).Fields(), 6)
+
+	bindNames := []string{}
+	for _, sf := range getStructPlan(reflect.TypeOf(m)).BindFields() {
+		bindNames = append(bindNames, sf.Name)
+	}
+	assert.Equal(t, []string{`ID`, `Created`, `Tags`, `Scores`, `Meta`, `Nick`, `Level`, `Weight`, `Remark`, `Quantity`}, bindNames)
+
+	r := &planTypes{}
+	err := New().Bind(url.Values{
+		`ID`:         {`7`},
+		`Created`:    {`2020-01-02 03:04`},
+		`Tags[]`:     {`b`, `c`},
+		`Scores`:     {`3, 4`},
+		`Meta.key`:   {`x`, ``, `y`},
+		`Meta.value`: {`1`, `ignored`, `2`},
+		`Nick`:       {``},
+		`Level`:      {`9`},
+		`Weight`:     {`-3`},
+		`Remark`:     {`hi`},
+		`Quantity`:   {`12`},
+	}, r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), r.ID)
+	assert.True(t, r.Created.Valid)
+	assert.Equal(t, []string{`b`, `c`}, r.Tags)
+	assert.Equal(t, []int{3, 4}, r.Scores)
+	assert.Equal(t, map[string]string{`x`: `1`, `y`: `2`}, r.Meta)
+	assert.Nil(t, r.Nick)
+	assert.Equal(t, uint8(9), r.Level)
+	assert.Equal(t, int32(-3), r.Weight)
+	assert.Equal(t, sql.NullString{String: `hi`, Valid: true}, r.Remark)
+	assert.Equal(t, sql.NullInt64{Int64: 12, Valid: true}, r.Quantity)
+}
+
+func BenchmarkParseModel(b *testing.B) {
+	m := &planModel{Name: `webx`, Age: 20, Address: &planAddress{City: `Beijing`}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		form := New()
+		form.ParseModel(m)
+	}
+}
+
+func BenchmarkBind(b *testing.B) {
+	values := map[string][]string{
+		`Name`:         {`webx`},
+		`Age`:          {`20`},
+		`Active`:       {`on`},
+		`Address.City`: {`Beijing`},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		form := New()
+		form.Bind(values, &planModel{})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+)
+
+// Populate 使用客户端提交的数据回填表单中已生成的字段（用于验证失败后重新显示表单）
+func (f *Form) Populate(values url.Values) *Form {
+	f.populateElements(f.FieldList, values)
+	f.layoutAddresses(f.FieldList)
+	return f
+}
+
+func (f *Form) populateElements(elements []config.FormElement, values url.Values) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case fields.FieldInterface:
+			f.populateField(v, values)
+		case *FieldSetType:
+			if v.addressParts != nil {
+				for _, part := range v.addressParts {
+					f.populateField(part, values)
+				}
+				continue
+			}
+			f.populateElements(v.FieldList, values)
+		case *LangSetType:
+			for _, language := range v.Languages {
+				f.populateElements(language.Fields(), values)
+			}
+		}
+	}
+}
+
+func (f *Form) populateField(field fields.FieldInterface, values url.Values) {
+	vals, ok := values[field.Name()]
+	if !ok {
+		vals, ok = values[field.Name()+`[]`]
+	}
+	switch field.ElementType() {
+	case common.BUTTON, common.SUBMIT, common.RESET, common.STATIC, common.PASSWORD, common.FILE, common.IMAGE:
+		// 按钮和静态内容无需回填，密码和文件不应回显
+		return
+	case common.CHECKBOX:
+		// 未勾选的复选框不会被提交，因此缺失即代表未选中
+		field.RemoveTag(`checked`)
+		if !ok || len(vals) == 0 {
+			field.SetSelected()
+			return
+		}
+		field.SetValue(vals[0])
+		field.AddSelected(vals[1:]...)
+	case common.TAGS:
+		// 支持多个值（脚本提交的 name[]）和以“,”分隔的文本
+		if !ok {
+			return
+		}
+		field.SetValue(strings.Join(fields.SplitTags(vals...), fields.TagsSeparator+` `))
+	case common.SWITCH:
+		// 关闭的开关不会被提交
+		if !ok || len(vals) == 0 {
+			field.SetValue(`0`)
+			return
+		}
+		field.SetValue(vals[0])
+	case common.SELECT:
+		if !ok {
+			return
+		}
+		if len(vals) == 0 {
+			field.SetSelected()
+			return
+		}
+		field.SetValue(vals[0])
+		field.AddSelected(vals[1:]...)
+	case common.RADIO:
+		if !ok || len(vals) == 0 {
+			return
+		}
+		field.SetValue(vals[0])
+	case common.TEXTAREA, common.RICHTEXT, common.MARKDOWN:
+		if !ok || len(vals) == 0 {
+			return
+		}
+		field.SetText(vals[0])
+	case common.MONEY, common.DECIMAL:
+		if !ok || len(vals) == 0 {
+			return
+		}
+		// 字段的值为规范格式，提交的本地化格式的数值需要先转换（无效的值原样回填）
+		value := vals[0]
+		if ff, y := field.(*fields.Field); y {
+			df := &decimalFormat{Precision: ff.Precision()}
+			if v, err := df.normalize(value, reflect.String, ff.NumberFormat()); err == nil {
+				value = v
+			}
+		}
+		field.SetValue(value)
+	case common.AUTOCOMPLETE:
+		if !ok || len(vals) == 0 {
+			return
+		}
+		field.SetValue(vals[0])
+		// 搜索框显示的是已选值的文本，需要重新查询
+		if ff, y := field.(*fields.Field); y {
+			ff.SetSelectedText(``)
+			f.applyLookup(ff, ff.Lookup())
+		}
+	default:
+		if !ok || len(vals) == 0 {
+			return
+		}
+		field.SetValue(vals[0])
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/coscms/forms/config"
+)
+
+// ErrRemoteValidatorNotFound 元素的远程验证器没有注册
+var ErrRemoteValidatorNotFound = errors.New(`remote validator not found`)
+
+// RemoteFieldParam 提交给远程验证地址的参数，值为要验证的字段名称
+var RemoteFieldParam = `_field`
+
+// RemoteValidator 远程验证器：value 为字段提交的值（已执行过滤器），values 为表单提交的全部数据。
+// 返回的错误信息作为验证错误显示（会经过表单的标签翻译函数）
+type RemoteValidator func(ctx context.Context, value string, values url.Values) error
+
+var (
+	remoteValidators   = map[string]RemoteValidator{}
+	remoteValidatorsMu sync.RWMutex
+)
+
+// RegisterRemoteValidator 注册可以被元素的 remote 属性或 form_remote 标签引用的远程验证器
+func RegisterRemoteValidator(name string, fn RemoteValidator) {
+	remoteValidatorsMu.Lock()
+	remoteValidators[name] = fn
+	remoteValidatorsMu.Unlock()
+}
+
+// GetRemoteValidator 返回已注册的远程验证器
+func GetRemoteValidator(name string) (RemoteValidator, bool) {
+	remoteValidatorsMu.RLock()
+	fn, ok := remoteValidators[name]
+	remoteValidatorsMu.RUnlock()
+	return fn, ok
+}
+
+// SetRemoteURL 设置远程验证地址（默认为配置中的 remoteURL）
+func (f *Form) SetRemoteURL(url string) *Form {
+	f.remoteURL = url
+	return f
+}
+
+// RemoteURL 返回远程验证地址
+func (f *Form) RemoteURL() string {
+	if len(f.remoteURL) == 0 && f.config != nil {
+		return f.config.RemoteURL
+	}
+	return f.remoteURL
+}
+
+// validRemote 使用远程验证器验证提交的值（空值不验证）
+func (f *Form) validRemote(name string, remote string, vals []string, values url.Values) error {
+	fn, ok := GetRemoteValidator(remote)
+	if !ok {
+		f.Validate().SetError(name, ErrInvalidValue.Error())
+		return fmt.Errorf(`%s: %w: %s`, name, ErrRemoteValidatorNotFound, remote)
+	}
+	for _, val := range vals {
+		if len(val) == 0 {
+			continue
+		}
+		if err := fn(f.Context(), val, values); err != nil {
+			f.Validate().SetError(name, err.Error())
+			return fmt.Errorf(`%s: %w`, name, err)
+		}
+	}
+	return nil
+}
+
+// RemoteResult 远程验证的结果
+type RemoteResult struct {
+	Valid   bool   `json:"valid"`
+	Field   string `json:"field"`
+	Message string `json:"message,omitempty"`
+}
+
+// RemoteHandler 远程验证接口：按照配置验证提交的数据中由 RemoteFieldParam 指定的单个字段（与 FilterByElement 相同：
+// 过滤器、输入掩码、valid 规则、选项查询函数和远程验证器），以 JSON 格式返回 RemoteResult。
+// 只能验证设置了 remote 的可编辑元素，其它字段返回 404。结构体的 form_remote 只在 Bind 时验证
+type RemoteHandler struct {
+	Config *config.Config
+	Setup  func(r *http.Request, form *Form) // 可选，为每次请求生成的表单设置语言、标签翻译函数或授权函数等
+}
+
+func (h *RemoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form := NewWithConfig(h.Config)
+	form.SetContext(r.Context())
+	if h.Setup != nil {
+		h.Setup(r, form)
+	}
+	name := r.Form.Get(RemoteFieldParam)
+	ele := form.config.GetElements()[name] // 包括展开的 subform 和 address 中的元素
+	if ele == nil || len(ele.Remote) == 0 || form.unwritableNames()[name] {
+		http.NotFound(w, r)
+		return
+	}
+	if ele.Name != name { // langset 中的元素以各语言的名称提交
+		ele = ele.Clone()
+		ele.Name = name
+	}
+	values := url.Values{}
+	for key, vals := range r.Form {
+		if key != RemoteFieldParam {
+			values[key] = vals
+		}
+	}
+	result := RemoteResult{Valid: true, Field: name}
+	if _, err := form.FilterByElement(values, url.Values{}, ele); err != nil {
+		result.Valid = false
+		result.Message = form.labelFn(err.Message)
+	}
+	w.Header().Set(`Content-Type`, `application/json; charset=utf-8`)
+	json.NewEncoder(w).Encode(result)
+}
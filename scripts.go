@@ -0,0 +1,80 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+)
+
+// ScriptsTemplate 返回表单组件脚本的模板（scripts.html，所有主题共用）
+func (f *Form) ScriptsTemplate() (*template.Template, error) {
+	tmpl := common.TmplDir(f.Theme) + `/scripts.html`
+	return common.GetOrSetCachedTemplate(tmpl, func() (*template.Template, error) {
+		return common.ParseFiles(common.LookupPath(tmpl))
+	})
+}
+
+// scriptsData 返回表单中需要脚本的组件（tags 字段和设置了输入掩码的字段），没有时返回 nil
+func (f *Form) scriptsData() map[string]interface{} {
+	data := map[string]interface{}{}
+	collectScripts(f.FieldList, data)
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+func collectScripts(elements []config.FormElement, data map[string]interface{}) {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case fields.FieldInterface:
+			if v.ElementType() == common.TAGS {
+				data["tags"] = true
+			}
+			if ff, ok := v.(*fields.Field); ok && len(ff.Mask()) > 0 {
+				data["mask"] = true
+			}
+		case *FieldSetType:
+			collectScripts(v.FieldList, data)
+		case *LangSetType:
+			for _, language := range v.Languages {
+				collectScripts(language.Fields(), data)
+			}
+		}
+	}
+}
+
+// renderScripts 输出表单中的组件使用的脚本（每个表单只输出一次）
+func (f *Form) renderScripts() template.HTML {
+	data := f.scriptsData()
+	if data == nil {
+		return ``
+	}
+	t, err := f.ScriptsTemplate()
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	buf := bytes.NewBuffer(nil)
+	if err = t.ExecuteTemplate(buf, `scripts`, data); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return template.HTML(buf.String())
+}
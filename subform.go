@@ -0,0 +1,174 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/coscms/forms/common"
+	"github.com/coscms/forms/config"
+)
+
+// ErrSubformNotFound subform 元素引用的表单配置不存在
+var ErrSubformNotFound = errors.New(`subform config not found`)
+
+var (
+	subforms   = map[string]*config.Config{}
+	subformsMu sync.RWMutex
+)
+
+// RegisterSubform 注册可以被 subform 元素（config 属性）引用的表单配置
+func RegisterSubform(name string, c *config.Config) {
+	subformsMu.Lock()
+	subforms[name] = c
+	subformsMu.Unlock()
+}
+
+// GetSubform 返回 subform 元素引用的表单配置：已注册的配置，或者扩展名为 .json、.json5 的配置文件
+func GetSubform(name string) (*config.Config, error) {
+	subformsMu.RLock()
+	c, ok := subforms[name]
+	subformsMu.RUnlock()
+	if ok {
+		return c, nil
+	}
+	switch filepath.Ext(name) {
+	case `.json`, `.json5`:
+		return UnmarshalFile(name)
+	}
+	return nil, fmt.Errorf(`%w: %s`, ErrSubformNotFound, name)
+}
+
+// expandConfig 返回展开了 subform 和 address 元素的配置。配置可能被多个表单共享（例如缓存的配置），
+// 需要展开时复制一份由表单使用，原配置不会被修改。同时返回无法展开的 subform 的错误
+func expandConfig(c *config.Config) (*config.Config, BuildErrors) {
+	if !hasUnexpanded(c.Elements) {
+		return c, nil
+	}
+	c = c.Clone()
+	errs := expandSubforms(c.Elements)
+	expandAddressElements(c.Elements)
+	return c, errs
+}
+
+// hasUnexpanded 是否有没有子元素的 subform 或 address 元素
+func hasUnexpanded(elements []*config.Element) bool {
+	for _, ele := range elements {
+		switch ele.Type {
+		case `langset`, `fieldset`:
+			if hasUnexpanded(ele.Elements) {
+				return true
+			}
+		case common.SUBFORM, common.ADDRESS:
+			if len(ele.Elements) == 0 || hasUnexpanded(ele.Elements) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandSubforms 复制 subform 元素引用的配置中的元素，按照 subform 的命名方式（Naming）加上其名称作为前缀后作为其子元素。
+// 已有子元素的 subform 不再展开，refs 为正在展开的配置名称（用于检测循环引用）。
+// 返回的错误以无法展开的 subform 的名称作为字段名
+func expandSubforms(elements []*config.Element, refs ...string) BuildErrors {
+	var errs BuildErrors
+	for _, ele := range elements {
+		switch ele.Type {
+		case `langset`, `fieldset`:
+			errs = append(errs, expandSubforms(ele.Elements, refs...)...)
+		case common.SUBFORM:
+			if len(ele.Elements) > 0 {
+				continue
+			}
+			var brackets bool
+			switch ele.Naming {
+			case ``, config.NamingDot:
+			case config.NamingBrackets:
+				brackets = true
+			default:
+				errs = append(errs, &BuildError{Field: ele.Name, Err: fmt.Errorf(`invalid subform naming: %s`, ele.Naming)})
+				continue
+			}
+			if slices.Contains(refs, ele.Config) {
+				errs = append(errs, &BuildError{Field: ele.Name, Err: fmt.Errorf(`circular subform reference to %s`, ele.Config)})
+				continue
+			}
+			c, err := GetSubform(ele.Config)
+			if err != nil {
+				errs = append(errs, &BuildError{Field: ele.Name, Err: err})
+				continue
+			}
+			subs := make([]*config.Element, len(c.Elements))
+			for i, sub := range c.Elements {
+				subs[i] = sub.Clone()
+			}
+			if subErrs := expandSubforms(subs, append(refs, ele.Config)...); len(subErrs) > 0 {
+				errs = append(errs, &BuildError{Field: ele.Name, Err: subErrs})
+				continue
+			}
+			prefixElementNames(subs, ele.Name, brackets)
+			ele.Elements = subs
+		}
+	}
+	return errs
+}
+
+// subformFailed 表单配置中名称为 name 的 subform 是否在 Init 中展开失败（错误已经记录）
+func (f *Form) subformFailed(name string) bool {
+	for _, err := range f.expandErrors {
+		if err.Field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixElementNames 为元素（包括其子元素）的名称加上前缀
+func prefixElementNames(elements []*config.Element, prefix string, brackets bool) {
+	for _, ele := range elements {
+		if len(ele.Name) > 0 {
+			ele.Name = prefixName(prefix, ele.Name, brackets)
+		}
+		for _, key := range []string{`structFieldName`, `originalName`} {
+			if name, ok := ele.Data[key].(string); ok && len(name) > 0 {
+				ele.Data[key] = prefixName(prefix, name, brackets)
+			}
+		}
+		prefixElementNames(ele.Elements, prefix, brackets)
+	}
+}
+
+// prefixName 为名称加上前缀：前缀或名称使用方括号（如“user[contact]”、“tags[name]”）或者 brackets 为 true 时
+// 返回“prefix[a][b]”，否则返回“prefix.a.b”。两种格式都可以被 splitFormNames 或 parseNameToStructFieldName 解析
+func prefixName(prefix string, name string, brackets bool) string {
+	isBracket := strings.HasSuffix(name, `]`)
+	if !brackets && !isBracket && !strings.HasSuffix(prefix, `]`) {
+		return prefix + `.` + name
+	}
+	var parts []string
+	if isBracket {
+		parts = splitFormNames(name)
+	} else {
+		parts = strings.Split(name, `.`)
+	}
+	return prefix + `[` + strings.Join(parts, `][`) + `]`
+}
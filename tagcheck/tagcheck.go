@@ -0,0 +1,430 @@
+/*
+
+   Copyright 2016-present Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+// Package tagcheck defines an analyzer that reports malformed form struct tags
+// (form_widget, form_choices, form_datalist, form_labels, form_mask, form_filter, form_params, form_min/form_max/form_step, form_maxlength, form_precision, form_rows/form_cols, form_options and valid)
+// of structs that have form_* tags at compile time instead of when the form is built.
+//
+// It can be run standalone with cmd/formtagcheck or with go vet:
+//
+//	go vet -vettool=$(which formtagcheck) ./...
+package tagcheck
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/com"
+	"github.com/webx-top/validation"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `check form struct tags
+
+The tagcheck analyzer reports unknown form_widget values, odd-length form_choices lists,
+unparsable form_params, invalid form_min/form_max/form_step/form_precision values, non-numeric
+form_rows/form_cols, unknown form_options, form_datalist on widgets without
+suggestions, form_labels on widgets other than switch and rating, form_mask on
+widgets other than text, tel and search, unknown form_filter filters, the address widget on
+non-struct fields and malformed valid rules.`
+
+// Analyzer reports malformed form struct tags.
+var Analyzer = &analysis.Analyzer{
+	Name:     `formtag`,
+	Doc:      doc,
+	Run:      run,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+}
+
+// validFuncs 通过 validation.AddCustomFunc 注册的自定义验证函数（以“,”分隔）
+var validFuncs string
+
+// filterNames 通过 fields.RegisterFilter 注册的自定义过滤器（以“,”分隔）
+var filterNames string
+
+func init() {
+	Analyzer.Flags.StringVar(&validFuncs, `valid.funcs`, ``, `comma-separated list of custom validation functions registered with validation.AddCustomFunc`)
+	Analyzer.Flags.StringVar(&filterNames, `filters`, ``, `comma-separated list of custom filters registered with fields.RegisterFilter`)
+}
+
+// form_options 中可以使用的选项
+var knownOptions = map[string]struct{}{
+	`-`: {}, `checked`: {}, `multiple`: {}, `forceSetValue`: {}, `output`: {},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	customFuncs := map[string]struct{}{}
+	for _, name := range strings.Split(validFuncs, `,`) {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			customFuncs[com.Title(name)] = struct{}{}
+		}
+	}
+	customFilters := map[string]struct{}{}
+	for _, name := range strings.Split(filterNames, `,`) {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			customFilters[name] = struct{}{}
+		}
+	}
+	insp.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		list := n.(*ast.StructType).Fields.List
+		if !hasFormTags(list) {
+			// valid tags of structs that are not forms may belong to other validation libraries
+			return
+		}
+		for _, field := range list {
+			tag, ok := fieldTag(field)
+			if !ok {
+				continue
+			}
+			c := &checker{
+				pass:          pass,
+				field:         field,
+				tag:           reflect.StructTag(tag),
+				typ:           pass.TypesInfo.TypeOf(field.Type),
+				customFuncs:   customFuncs,
+				customFilters: customFilters,
+			}
+			c.check()
+		}
+	})
+	return nil, nil
+}
+
+var formTagKey = regexp.MustCompile(`(?:^|\s)form_\w+:"`)
+
+// hasFormTags reports whether one of the fields has a form_* tag.
+func hasFormTags(list []*ast.Field) bool {
+	for _, field := range list {
+		if tag, ok := fieldTag(field); ok && formTagKey.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return ``, false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	return tag, err == nil
+}
+
+type checker struct {
+	pass          *analysis.Pass
+	field         *ast.Field
+	tag           reflect.StructTag
+	typ           types.Type
+	customFuncs   map[string]struct{}
+	customFilters map[string]struct{}
+}
+
+func (c *checker) reportf(format string, args ...interface{}) {
+	c.pass.Reportf(c.field.Tag.Pos(), format, args...)
+}
+
+func (c *checker) check() {
+	widget, hasWidget := c.tag.Lookup(`form_widget`)
+	if hasWidget {
+		if _, ok := fields.StructWidgets[widget]; !ok {
+			c.reportf(`unknown form_widget %q`, widget)
+			return
+		}
+	}
+	if options, ok := c.tag.Lookup(`form_options`); ok {
+		for _, opt := range strings.Split(options, `;`) {
+			if _, ok := knownOptions[opt]; !ok && len(opt) > 0 {
+				c.reportf(`unknown form_options value %q`, opt)
+			}
+		}
+	}
+	choices, hasChoices := c.tag.Lookup(`form_choices`)
+	if !hasWidget {
+		widget = c.inferWidget(hasChoices)
+	}
+	if hasChoices {
+		c.checkChoices(widget, choices)
+	}
+	if _, ok := c.tag.Lookup(`form_datalist`); ok && !fields.IsDatalistType(widget) {
+		c.reportf(`form_datalist is ignored by widget %q`, widget)
+	}
+	if _, ok := c.tag.Lookup(`form_mask`); ok && !fields.IsMaskType(widget) {
+		c.reportf(`form_mask is ignored by widget %q`, widget)
+	}
+	if filters, ok := c.tag.Lookup(`form_filter`); ok {
+		for _, name := range fields.ParseFilters(filters) {
+			if _, ok := c.customFilters[name]; ok {
+				continue
+			}
+			if _, ok := fields.GetFilter(name); !ok {
+				c.reportf(`unknown filter %q in form_filter`, name)
+			}
+		}
+	}
+	if labels, ok := c.tag.Lookup(`form_labels`); ok {
+		switch widget {
+		case `switch`:
+			if n := len(strings.Split(labels, `|`)); n != 2 {
+				c.reportf(`form_labels for switch must be on|off labels, got %d items`, n)
+			}
+		case `rating`:
+		default:
+			c.reportf(`form_labels is ignored by widget %q`, widget)
+		}
+	}
+	if widget == `address` && c.typ != nil && !isStruct(c.typ) {
+		c.reportf(`form_widget "address" requires a struct field, got %s`, c.typ)
+	}
+	if params, ok := c.tag.Lookup(`form_params`); ok {
+		if _, err := url.ParseQuery(params); err != nil {
+			c.reportf(`invalid form_params %q: %v`, params, err)
+		}
+	}
+	c.checkBounds(widget)
+	for _, key := range []string{`form_rows`, `form_cols`} {
+		if v, ok := c.tag.Lookup(key); ok {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				c.reportf(`%s must be a non-negative integer, got %q`, key, v)
+			}
+		}
+	}
+	if rule, ok := c.tag.Lookup(`valid`); ok {
+		if err := c.checkValid(rule); err != nil {
+			c.reportf(`invalid valid rule %q: %v`, rule, err)
+		}
+	}
+}
+
+// inferWidget 推断未指定 form_widget 的字段使用的控件（与 unWindStructure 一致）
+func (c *checker) inferWidget(hasChoices bool) string {
+	if c.typ == nil {
+		return ``
+	}
+	t := c.typ
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		t = p.Elem()
+	}
+	if isNamed(t, `time`, `Time`) || isNamed(t, `database/sql`, `NullTime`) {
+		return `datetime`
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return `text`
+		}
+		if hasChoices {
+			return `select`
+		}
+	case *types.Array:
+		if hasChoices {
+			return `select`
+		}
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsNumeric != 0:
+			return `number`
+		case u.Info()&types.IsBoolean != 0:
+			return `checkbox`
+		case u.Info()&types.IsString != 0:
+			if v, _ := c.tag.Lookup(`form_choices`); strings.HasPrefix(v, fields.ChoiceProviderPrefix) {
+				return `select`
+			}
+			return `text`
+		}
+	}
+	return ``
+}
+
+func isNamed(t types.Type, pkgPath string, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+// isStruct 是否为结构体或结构体指针
+func isStruct(t types.Type) bool {
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		t = p.Elem()
+	}
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+func (c *checker) checkChoices(widget string, choices string) {
+	if name, ok := fields.ChoiceProviderName(choices); ok {
+		if len(name) == 0 {
+			c.reportf(`form_choices provider name is empty`)
+		}
+		return
+	}
+	items := strings.Split(choices, `|`)
+	switch widget {
+	case `select`:
+		if len(items)%3 != 0 {
+			c.reportf(`form_choices for select must be a list of group|id|label triples, got %d items`, len(items))
+		}
+	case `radio`, `checkbox`, `range`, `tags`:
+		if len(items)%2 != 0 {
+			c.reportf(`form_choices for %s must be a list of id|label pairs, got %d items`, widget, len(items))
+		}
+	case ``:
+		c.reportf(`form_choices is ignored by this field type`)
+	default:
+		c.reportf(`form_choices is ignored by widget %q`, widget)
+	}
+}
+
+func (c *checker) checkBounds(widget string) {
+	var format string
+	switch widget {
+	case `date`, `datetime`, `datetime-local`, `time`, `month`, `week`:
+		step, _ := c.tag.Lookup(`form_step`)
+		format = fields.TimeFormat(widget, step)
+		if len(step) > 0 && step != `any` {
+			if _, err := strconv.ParseFloat(step, 64); err != nil {
+				c.reportf(`form_step must be a number, got %q`, step)
+			}
+		}
+	case `money`, `decimal`:
+		precision, err := fields.DecimalPrecision(c.tag.Get(`form_precision`))
+		if err != nil {
+			c.reportf(`form_precision: %v`, err)
+		}
+		for _, key := range []string{`form_min`, `form_max`, `form_value`} {
+			if v, ok := c.tag.Lookup(key); ok && len(v) > 0 {
+				if _, err := fields.ParseDecimal(v, precision, fields.CanonicalNumberFormat); err != nil {
+					c.reportf(`%s: %v`, key, err)
+				}
+			}
+		}
+		return
+	case `tags`:
+		if _, _, err := fields.ParseTagsLimit(c.tag.Get(`form_max`), c.tag.Get(`form_maxlength`)); err != nil {
+			c.reportf(`invalid tags limit: %v`, err)
+		}
+		return
+	case `rating`:
+		if v, ok := c.tag.Lookup(`form_max`); ok {
+			if n, err := strconv.Atoi(v); err != nil || n < 1 {
+				c.reportf(`form_max of rating must be a positive integer, got %q`, v)
+			}
+		}
+		return
+	case `number`, `range`:
+		for _, key := range []string{`form_min`, `form_max`, `form_step`} {
+			if v, ok := c.tag.Lookup(key); ok {
+				if _, err := strconv.ParseFloat(v, 64); err != nil && v != `any` {
+					c.reportf(`%s must be a number, got %q`, key, v)
+				}
+			}
+		}
+		return
+	default:
+		return
+	}
+	if v, ok := c.tag.Lookup(`form_format`); ok && len(v) > 0 {
+		format = v
+	}
+	for _, key := range []string{`form_min`, `form_max`} {
+		if v, ok := c.tag.Lookup(key); ok && len(v) > 0 {
+			if _, err := fields.ParseTime(v, format, time.UTC); err != nil {
+				c.reportf(`%s %q does not match the %s format %q`, key, v, widget, format)
+			}
+		}
+	}
+}
+
+var (
+	validationType = reflect.TypeOf(&validation.Validation{})
+
+	// 不属于验证函数的方法
+	nonValidFuncs = map[string]struct{}{
+		`Clear`: {}, `HasErrors`: {}, `HasError`: {}, `ErrorMap`: {}, `Error`: {}, `Check`: {}, `Valid`: {}, `NoMatch`: {},
+	}
+)
+
+// checkValid 检查验证规则的语法（与 validation 包的解析规则一致）
+func (c *checker) checkValid(rule string) error {
+	rule = strings.TrimSpace(rule)
+	if index := strings.Index(rule, `match(/`); index != -1 {
+		end := strings.LastIndex(rule, `/)`)
+		if end < index {
+			return errors.New(`invalid match function`)
+		}
+		if _, err := regexp.Compile(rule[index+len(`match(/`) : end]); err != nil {
+			return err
+		}
+		rule = strings.TrimSpace(rule[:index]) + strings.TrimSpace(rule[end+len(`/)`):])
+	}
+	for _, fn := range strings.Split(rule, `;`) {
+		fn = strings.TrimSpace(fn)
+		if len(fn) == 0 {
+			continue
+		}
+		name := fn
+		params := -1
+		if start := strings.Index(fn, `(`); start != -1 {
+			end := strings.Index(fn, `)`)
+			if end == -1 {
+				return fmt.Errorf(`missing ")" in %s`, fn)
+			}
+			name = strings.TrimSpace(fn[:start])
+			params = len(strings.Split(fn[start+1:end], `,`))
+		}
+		name = com.Title(name)
+		if _, ok := c.customFuncs[name]; ok {
+			continue
+		}
+		method, ok := validationType.MethodByName(name)
+		if _, unFunc := nonValidFuncs[name]; !ok || unFunc {
+			return fmt.Errorf(`unknown validation function %s`, name)
+		}
+		// 除去接收者、待验证的值和 key 之外的参数个数
+		num := method.Type.NumIn() - 3
+		if params < 0 {
+			params = 0
+		}
+		if num != params {
+			return fmt.Errorf(`%s requires %d parameters, got %d`, name, num, params)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package a
+
+import "time"
+
+type User struct {
+	Name     string    `form_widget:"txt"`                                    // want `unknown form_widget "txt"`
+	Gender   string    `form_widget:"radio" form_choices:"m|Male|f"`          // want `form_choices for radio must be a list of id\|label pairs, got 3 items`
+	Country  string    `form_widget:"select" form_choices:"|cn|China|us|USA"` // want `form_choices for select must be a list of group\|id\|label triples, got 5 items`
+	Tags     []string  `form_choices:"|a|A||b|B"`
+	City     string    `form_choices:"provider:cities"`
+	Params   string    `form_params:"a=%zz"`                      // want `invalid form_params`
+	Birthday time.Time `form_widget:"date" form_min:"2020-13-01"` // want `form_min "2020-13-01" does not match the date format "2006-01-02"`
+	Created  time.Time `form_max:"2020-01-01 10:00"`
+	Content  string    `form_widget:"textarea" form_rows:"ten"`        // want `form_rows must be a non-negative integer, got "ten"`
+	Age      int       `form_min:"zero" valid:"required;range(1,150)"` // want `form_min must be a number, got "zero"`
+	Email    string    `valid:"required;emial"`                        // want `unknown validation function Emial`
+	Size     string    `valid:"maxSize(1,2)"`                          // want `MaxSize requires 1 parameters, got 2`
+	Active   bool      `form_options:"checkd"`                         // want `unknown form_options value "checkd"`
+	Color    string    `form_datalist:"red|green"`
+	Agree    bool      `form_datalist:"yes"`                                 // want `form_datalist is ignored by widget "checkbox"`
+	Price    int64     `form_widget:"money" form_max:"9.999"`                // want `form_max: decimal number "9.999" has more than 2 fraction digits`
+	Notify   bool      `form_widget:"switch" form_labels:"On"`               // want `form_labels for switch must be on\|off labels, got 1 items`
+	Score    int       `form_widget:"rating" form_max:"0"`                   // want `form_max of rating must be a positive integer, got "0"`
+	Keywords []string  `form_widget:"tags" form_max:"5" form_maxlength:"-1"` // want `invalid tags limit: maxlength must be a non-negative integer, got "-1"`
+	Phone    string    `form_widget:"tel" form_mask:"(999) 999-9999" valid:"required"`
+	Zip      int       `form_mask:"99999"` // want `form_mask is ignored by widget "number"`
+	Volume   int       `form_widget:"range" form_options:"output" form_choices:"0|Low|10|High"`
+	Shipping *Address  `form_widget:"address"`
+	Billing  string    `form_widget:"address"` // want `form_widget "address" requires a struct field, got string`
+	Nickname string    `form_filter:"trim;collapse"`
+	Handle   string    `form_filter:"trim;lowercase"` // want `unknown filter "lowercase" in form_filter`
+}
+
+type Address struct {
+	Street     string
+	City       string
+	PostalCode string
+	Country    string
+}
+
+// Request is validated by another library that also uses the valid tag key.
+type Request struct {
+	Email string `valid:"email,required"`
+	Size  int    `valid:"between(1|10)"`
+}
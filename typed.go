@@ -0,0 +1,202 @@
+/*
+Copyright 2016-present Wenhui Shen <www.webx.top>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package forms
+
+import (
+	"errors"
+	"html/template"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/forms/config"
+	"github.com/coscms/forms/fields"
+	"github.com/webx-top/validation"
+)
+
+// ValidationResult 绑定和验证的结果
+type ValidationResult struct {
+	Errors []*validation.ValidationError
+	// BindError Form.Bind 返回的错误（例如严格模式下的 UnexpectedFieldsError 或者过滤器的错误）
+	BindError error
+}
+
+// OK 是否通过验证
+func (r ValidationResult) OK() bool {
+	return len(r.Errors) == 0 && r.BindError == nil
+}
+
+// Error 返回第一个错误，优先返回 BindError（没有错误时返回nil）
+func (r ValidationResult) Error() error {
+	if r.BindError != nil {
+		return r.BindError
+	}
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[0]
+}
+
+// ErrorMap 返回以字段名为键的错误信息
+func (r ValidationResult) ErrorMap() map[string]string {
+	errs := make(map[string]string, len(r.Errors))
+	for _, err := range r.Errors {
+		if _, ok := errs[err.Field]; !ok {
+			errs[err.Field] = err.Message
+		}
+	}
+	return errs
+}
+
+// TypedForm 模型类型在编译期确定的表单。
+// Form 不是并发安全的，所以 TypedForm 在每次渲染或绑定时都会创建新的 Form，而结构体的元数据只在创建时解析一次。
+//
+//	userForm := forms.NewTyped[User]()
+//	html := userForm.Render(&User{})
+//	user, result := userForm.Bind(r.PostForm)
+type TypedForm[T any] struct {
+	config  *config.Config
+	setters []func(*Form)
+}
+
+// NewTyped 创建泛型表单。如果指定了配置则按配置生成表单，否则按结构体 T 生成表单。
+// T 必须为结构体类型。
+func NewTyped[T any](c ...*config.Config) *TypedForm[T] {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(`forms: NewTyped requires a struct type, got ` + t.String())
+	}
+	tf := &TypedForm[T]{}
+	if len(c) > 0 {
+		tf.config = c[0]
+	}
+	return tf
+}
+
+// With 添加在每次创建 Form 时执行的设置函数（如 SetLabelFunc 等）
+func (t *TypedForm[T]) With(fn func(*Form)) *TypedForm[T] {
+	t.setters = append(t.setters, fn)
+	return t
+}
+
+// Config 返回表单配置
+func (t *TypedForm[T]) Config() *config.Config {
+	return t.config
+}
+
+func (t *TypedForm[T]) newForm(m *T) *Form {
+	var form *Form
+	if t.config != nil {
+		form = NewWithConfig(t.config, m)
+	} else {
+		form = New()
+		form.SetModel(m)
+	}
+	for _, fn := range t.setters {
+		fn(form)
+	}
+	return form
+}
+
+// Form 创建已解析模型 m 的表单（m 为 nil 时使用零值）
+func (t *TypedForm[T]) Form(m *T) *Form {
+	if m == nil {
+		m = new(T)
+	}
+	form := t.newForm(m)
+	if t.config != nil {
+		form.ParseFromConfig()
+	} else {
+		form.ParseModel(m)
+	}
+	return form
+}
+
+// Render 渲染模型 m 的表单，可传入 Bind 返回的结果以显示错误信息
+func (t *TypedForm[T]) Render(m *T, results ...ValidationResult) template.HTML {
+	form := t.Form(m)
+	for _, result := range results {
+		for _, err := range result.Errors {
+			name := structFieldName(reflect.TypeFor[T](), err.Field)
+			if field := findField(form.FieldList, name); field != nil {
+				field.AddError(form.labelFn(err.Message))
+			}
+		}
+	}
+	return form.Render()
+}
+
+// Bind 将客户端提交的数据绑定到新的模型实例并进行验证，Form.Bind 返回的错误记录在结果的 BindError 中。
+// 严格模式下提交了表单中不存在的字段时不绑定任何值，所以也不再验证模型。
+func (t *TypedForm[T]) Bind(values url.Values) (*T, ValidationResult) {
+	m := new(T)
+	form := t.newForm(m)
+	err := form.Bind(values, m)
+	var unexpected *UnexpectedFieldsError
+	if !errors.As(err, &unexpected) {
+		form.ValidModel(m)
+	}
+	return m, ValidationResult{Errors: form.Errors(), BindError: err}
+}
+
+// structFieldName 将验证错误的字段路径（例如 Base.Address.City）转换为表单字段名称：匿名嵌入的结构体展开时不加前缀
+func structFieldName(t reflect.Type, path string) string {
+	parts := strings.Split(path, `.`)
+	names := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			names = append(names, parts[i:]...)
+			break
+		}
+		sf, ok := t.FieldByName(part)
+		if !ok {
+			names = append(names, parts[i:]...)
+			break
+		}
+		t = sf.Type
+		if sf.Anonymous && i < len(parts)-1 {
+			continue
+		}
+		names = append(names, part)
+	}
+	return strings.Join(names, `.`)
+}
+
+// findField 在表单元素（包括 fieldset 和 langset 的子元素）中查找名称为 name 的字段
+func findField(elements []config.FormElement, name string) fields.FieldInterface {
+	for _, elem := range elements {
+		switch v := elem.(type) {
+		case fields.FieldInterface:
+			if v.OriginalName() == name || v.Name() == name {
+				return v
+			}
+		case *FieldSetType:
+			if field := findField(v.FieldList, name); field != nil {
+				return field
+			}
+		case *LangSetType:
+			for _, language := range v.Languages {
+				if field := findField(language.Fields(), name); field != nil {
+					return field
+				}
+			}
+		}
+	}
+	return nil
+}
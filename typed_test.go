@@ -0,0 +1,103 @@
+package forms_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coscms/forms"
+	"github.com/coscms/forms/common"
+	_ "github.com/coscms/forms/defaults"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedProfile struct {
+	Nickname string
+}
+
+type typedUser struct {
+	Name     string `valid:"required"`
+	Age      int
+	Score    float64
+	Active   bool
+	Birthday time.Time `form_widget:"date" form_format:"2006-01-02"`
+	Profile  typedProfile
+}
+
+func TestTypedForm(t *testing.T) {
+	tf := forms.NewTyped[typedUser]()
+	html := string(tf.Render(&typedUser{Name: `webx`}))
+	assert.True(t, strings.Contains(html, `name="Name"`))
+	assert.True(t, strings.Contains(html, `value="webx"`))
+	assert.True(t, strings.Contains(html, `name="Profile.Nickname"`))
+
+	user, result := tf.Bind(url.Values{
+		`Name`:             {`coscms`},
+		`Age`:              {`20`},
+		`Score`:            {`9.5`},
+		`Active`:           {`on`},
+		`Birthday`:         {`1985-03-04`},
+		`Profile.Nickname`: {`cos`},
+	})
+	assert.True(t, result.OK())
+	assert.Equal(t, `coscms`, user.Name)
+	assert.Equal(t, 20, user.Age)
+	assert.Equal(t, 9.5, user.Score)
+	assert.True(t, user.Active)
+	assert.Equal(t, `1985-03-04`, user.Birthday.Format(`2006-01-02`))
+	assert.Equal(t, `cos`, user.Profile.Nickname)
+
+	_, result = tf.Bind(url.Values{`Age`: {`abc`}})
+	assert.False(t, result.OK())
+	errs := result.ErrorMap()
+	assert.Contains(t, errs, `Age`)
+	assert.Contains(t, errs, `Name`)
+}
+
+type TypedBase struct {
+	Code string `valid:"required"`
+}
+
+type typedAccount struct {
+	TypedBase
+	Profile struct {
+		Nickname string `valid:"required"`
+	}
+}
+
+func TestTypedFormNestedErrors(t *testing.T) {
+	tf := forms.NewTyped[typedAccount]().With(func(f *forms.Form) { f.Theme = common.BOOTSTRAP })
+	m, result := tf.Bind(url.Values{})
+	errs := result.ErrorMap()
+	assert.Contains(t, errs, `TypedBase.Code`)
+	assert.Contains(t, errs, `Profile.Nickname`)
+	html := string(tf.Render(m, result))
+	assert.Equal(t, 2, strings.Count(html, `Can not be empty`))
+}
+
+type typedStrict struct {
+	Name string `valid:"required"`
+}
+
+func TestTypedFormStrictBind(t *testing.T) {
+	tf := forms.NewTyped[typedStrict]().With(func(f *forms.Form) { f.SetStrictBind(true) })
+	m, result := tf.Bind(url.Values{`Name`: {`a`}, `IsRoot`: {`1`}})
+	assert.False(t, result.OK())
+	assert.Empty(t, result.Errors)
+	var unexpected *forms.UnexpectedFieldsError
+	assert.True(t, errors.As(result.Error(), &unexpected))
+	assert.Equal(t, []string{`IsRoot`}, unexpected.Names)
+	assert.Empty(t, m.Name)
+
+	m, result = tf.Bind(url.Values{`Name`: {`a`}})
+	assert.True(t, result.OK())
+	assert.Equal(t, `a`, m.Name)
+
+	_, result = forms.NewTyped[struct {
+		Name string `form_filter:"nosuchfilter"`
+	}]().Bind(url.Values{`Name`: {`a`}})
+	assert.False(t, result.OK())
+	assert.ErrorContains(t, result.BindError, `nosuchfilter`)
+}
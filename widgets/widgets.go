@@ -53,7 +53,17 @@ func (w *Widget) Render(data interface{}) string {
 }
 
 // BaseWidget creates a Widget based on theme and inpuType parameters, both defined in the common package.
+// It panics if the template cannot be parsed, use BaseWidgetE to get an error instead.
 func BaseWidget(theme, inputType, tmplName string) *Widget {
+	w, err := BaseWidgetE(theme, inputType, tmplName)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// BaseWidgetE is like BaseWidget but returns an error if the template cannot be parsed.
+func BaseWidgetE(theme, inputType, tmplName string) (*Widget, error) {
 	cachedKey := theme + ", " + inputType + ", " + tmplName
 	tmpl, err := common.GetOrSetCachedTemplate(cachedKey, func() (*template.Template, error) {
 		fpath := common.TmplDir(theme) + "/" + theme + "/"
@@ -63,10 +73,19 @@ func BaseWidget(theme, inputType, tmplName string) *Widget {
 		return common.ParseFiles(urls...)
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	tmpl.Funcs(common.TplFuncs())
-	return New(tmpl)
+	return New(tmpl), nil
+}
+
+// ErrorWidget a widget that renders the error message of a template that cannot be parsed
+type ErrorWidget struct {
+	Err error
+}
+
+func (w *ErrorWidget) Render(data interface{}) string {
+	return template.HTMLEscapeString(w.Err.Error())
 }
 
 func widgetTmpl(inputType, tmpl string) (tpath string) {
@@ -81,6 +100,16 @@ func widgetTmpl(inputType, tmpl string) (tpath string) {
 		if len(tmpl) > 0 {
 			tpath = "text/" + tmpl
 		}
+	case common.TAGS:
+		tpath = "text/tags"
+		if len(tmpl) > 0 {
+			tpath = "text/" + tmpl
+		}
+	case common.RICHTEXT, common.MARKDOWN:
+		tpath = "text/editor"
+		if len(tmpl) > 0 {
+			tpath = "text/" + tmpl
+		}
 	case common.PASSWORD:
 		tpath = "text/passwordinput"
 		if len(tmpl) > 0 {
@@ -106,6 +135,16 @@ func widgetTmpl(inputType, tmpl string) (tpath string) {
 		if len(tmpl) > 0 {
 			tpath = "options/" + tmpl
 		}
+	case common.SWITCH:
+		tpath = "options/switch"
+		if len(tmpl) > 0 {
+			tpath = "options/" + tmpl
+		}
+	case common.RATING:
+		tpath = "options/rating"
+		if len(tmpl) > 0 {
+			tpath = "options/" + tmpl
+		}
 	case common.RANGE:
 		tpath = "number/range"
 		if len(tmpl) > 0 {
@@ -141,6 +180,16 @@ func widgetTmpl(inputType, tmpl string) (tpath string) {
 		if len(tmpl) > 0 {
 			tpath = "datetime/" + tmpl
 		}
+	case common.MONEY, common.DECIMAL:
+		tpath = "number/money"
+		if len(tmpl) > 0 {
+			tpath = "number/" + tmpl
+		}
+	case common.AUTOCOMPLETE:
+		tpath = "text/autocomplete"
+		if len(tmpl) > 0 {
+			tpath = "text/" + tmpl
+		}
 	case common.STATIC:
 		tpath = "static"
 		if len(tmpl) > 0 {